@@ -13,10 +13,7 @@ import (
 	"go/scanner"
 	"io"
 	"io/fs"
-	"math/rand"
 	"os"
-	"path/filepath"
-	"strconv"
 
 	"golang.org/x/sync/semaphore"
 )
@@ -73,6 +70,16 @@ const exclusive = -1
 // If f returns a non-nil error, that error will be reported after f's output
 // (if any) and will cause a nonzero final exit code.
 func (s *sequencer) Add(weight int64, f func(*reporter) error) {
+	s.add(weight, "", f)
+}
+
+// AddFile is like Add, for a task processing a file:
+// the path is included in the error reported if f panics.
+func (s *sequencer) AddFile(path string, weight int64, f func(*reporter) error) {
+	s.add(weight, path, f)
+}
+
+func (s *sequencer) add(weight int64, path string, f func(*reporter) error) {
 	if weight < 0 || weight > s.maxWeight {
 		weight = s.maxWeight
 	}
@@ -89,7 +96,7 @@ func (s *sequencer) Add(weight int64, f func(*reporter) error) {
 	// Start f in parallel: it can run until it invokes a method on r, at which
 	// point it will block until the previous task releases the output state.
 	go func() {
-		if err := f(r); err != nil {
+		if err := runTask(path, f, r); err != nil {
 			r.Report(err)
 		}
 		next <- r.getState() // Release the next task.
@@ -126,6 +133,9 @@ type reporter struct {
 type reporterState struct {
 	out, err io.Writer
 	exitCode int
+
+	// Counts of the summary (see report.go).
+	checked, changed, errored int
 }
 
 // getState blocks until any prior reporters are finished with the reporter
@@ -160,6 +170,7 @@ func (r *reporter) Report(err error) {
 	st := r.getState()
 	scanner.PrintError(st.err, err)
 	st.exitCode = 2
+	st.errored++
 }
 
 func (r *reporter) ExitCode() int {
@@ -248,115 +259,3 @@ func fileWeight(path string, info fs.FileInfo) int64 {
 	}
 	return info.Size()
 }
-
-// writeFile updates a file with the new formatted data.
-func writeFile(filename string, orig, formatted []byte, perm fs.FileMode, size int64) error {
-	// Make a temporary backup file before rewriting the original file.
-	bakname, err := backupFile(filename, orig, perm)
-	if err != nil {
-		return err
-	}
-
-	fdSem <- true
-	defer func() { <-fdSem }()
-
-	fout, err := os.OpenFile(filename, os.O_WRONLY, perm)
-	if err != nil {
-		// We couldn't even open the file, so it should
-		// not have changed.
-		os.Remove(bakname)
-		return err
-	}
-	defer fout.Close() // for error paths
-
-	restoreFail := func(err error) {
-		fmt.Fprintf(os.Stderr, "gofmt: %s: error restoring file to original: %v; backup in %s\n", filename, err, bakname)
-	}
-
-	n, err := fout.Write(formatted)
-	if err == nil && int64(n) < size {
-		err = fout.Truncate(int64(n))
-	}
-
-	if err != nil {
-		// Rewriting the file failed.
-
-		if n == 0 {
-			// Original file unchanged.
-			os.Remove(bakname)
-			return err
-		}
-
-		// Try to restore the original contents.
-
-		no, erro := fout.WriteAt(orig, 0)
-		if erro != nil {
-			// That failed too.
-			restoreFail(erro)
-			return err
-		}
-
-		if no < n {
-			// Original file is shorter. Truncate.
-			if erro = fout.Truncate(int64(no)); erro != nil {
-				restoreFail(erro)
-				return err
-			}
-		}
-
-		if erro := fout.Close(); erro != nil {
-			restoreFail(erro)
-			return err
-		}
-
-		// Original contents restored.
-		os.Remove(bakname)
-		return err
-	}
-
-	if err := fout.Close(); err != nil {
-		restoreFail(err)
-		return err
-	}
-
-	// File updated.
-	os.Remove(bakname)
-	return nil
-}
-
-// backupFile writes data to a new file named filename<number> with permissions perm,
-// with <number> randomly chosen such that the file name is unique. backupFile returns
-// the chosen file name.
-func backupFile(filename string, data []byte, perm fs.FileMode) (string, error) {
-	fdSem <- true
-	defer func() { <-fdSem }()
-
-	nextRandom := func() string {
-		return strconv.Itoa(rand.Int())
-	}
-
-	dir, base := filepath.Split(filename)
-	var (
-		bakname string
-		f       *os.File
-	)
-	for {
-		bakname = filepath.Join(dir, base+"."+nextRandom())
-		var err error
-		f, err = os.OpenFile(bakname, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
-		if err == nil {
-			break
-		}
-		if !os.IsExist(err) {
-			return "", err
-		}
-	}
-
-	// write data to backup file
-	_, err := f.Write(data)
-	if err1 := f.Close(); err == nil {
-		err = err1
-	}
-
-	return bakname, err
-}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/golangci/golines/internal/diff"
+	"github.com/golangci/golines/shorten"
+)
+
+// explain adds a task printing how the shortener handles a line, given as `file.go:line`.
+func (r *Runner) explain(s *sequencer, location string) {
+	s.Add(0, func(rp *reporter) error {
+		filename, line, err := parseLocation(location)
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := r.extraFormatter.Format(context.Background(), content)
+		if err != nil {
+			return shorten.NewError(shorten.StageBaseFormatter, filename, err)
+		}
+
+		// The line is a line of the file, before the base formatter (e.g., goimports adding imports).
+		if nbLines := strings.Count(strings.TrimSuffix(string(content), "\n"), "\n") + 1; line > nbLines {
+			return fmt.Errorf("line %d out of range: the file has %d lines", line, nbLines)
+		}
+
+		index, ok := diff.MapLine(content, formatted, line-1)
+		if !ok {
+			return fmt.Errorf("line %d is removed by the base formatter", line)
+		}
+
+		explanation, err := r.shortener.Explain(filename, formatted, index+1)
+		if err != nil {
+			return err
+		}
+
+		explanation.Line = line
+
+		_, _ = rp.Write(formatExplanation(explanation))
+
+		return nil
+	})
+}
+
+// parseLocation parses a location like `file.go:123`.
+func parseLocation(location string) (string, int, error) {
+	i := strings.LastIndex(location, ":")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid location %q: expected file.go:line", location)
+	}
+
+	line, err := strconv.Atoi(location[i+1:])
+	if err != nil || line < 1 {
+		return "", 0, fmt.Errorf("invalid line in location %q: expected file.go:line", location)
+	}
+
+	return location[:i], line, nil
+}
+
+// formatExplanation formats an explanation for the console.
+func formatExplanation(e *shorten.Explanation) []byte {
+	var b bytes.Buffer
+
+	_, _ = fmt.Fprintf(&b, "%s:%d: length %d, maximum %d\n", e.File, e.Line, e.Length, e.MaxLen)
+
+	if e.FormattedLine != e.Line {
+		// The positions of the rounds are the ones of the formatted content.
+		_, _ = fmt.Fprintf(&b, "(line %d after the formatting)\n", e.FormattedLine)
+	}
+
+	_, _ = fmt.Fprintf(&b, "\t%s\n", strings.TrimSpace(e.Text))
+
+	if len(e.Path) > 0 {
+		b.WriteString("\nSyntax tree path:\n")
+
+		for i, elt := range e.Path {
+			_, _ = fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", i+1), elt)
+		}
+	}
+
+	for _, round := range e.Rounds {
+		_, _ = fmt.Fprintf(&b, "\nRound %d:\n", round.Round)
+
+		if len(round.Events) == 0 {
+			b.WriteString("  no rule applied\n")
+		}
+
+		for _, event := range round.Events {
+			converged := "converged"
+			if !event.Converged {
+				converged = "not converged"
+			}
+
+			_, _ = fmt.Fprintf(&b, "  %d:%d %s: %s (%d -> %d, %s)\n",
+				event.Line, event.Column, event.Node, event.Rule, event.LengthBefore, event.LengthAfter, converged)
+		}
+
+		b.WriteString("  result:\n")
+
+		for line := range strings.Lines(round.Text + "\n") {
+			_, _ = fmt.Fprintf(&b, "  | %s", line)
+		}
+	}
+
+	_, _ = fmt.Fprintf(&b, "\nStopped: %s\n", e.Reason)
+
+	return b.Bytes()
+}
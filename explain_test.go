@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golines/internal/formatter"
+	"github.com/golangci/golines/shorten"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runner_explain(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"test.go": `package main
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+`,
+	}, root)
+
+	config := shorten.NewDefaultConfig()
+	config.MaxLen = 40
+
+	runner := NewRunner()
+	runner.shortener = shorten.NewShortener(config)
+
+	var stdout bytes.Buffer
+
+	s := newSequencer(1, &stdout, os.Stderr)
+
+	runner.explain(s, filepath.Join(root, "test.go")+":4")
+	require.Equal(t, 0, s.GetExitCode())
+
+	expected := filepath.Join(root, "test.go") + `:4: length 63, maximum 40
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+
+Syntax tree path:
+  File
+    Decls: FuncDecl
+      Body: BlockStmt
+        List: ExprStmt
+          X: CallExpr
+
+Round 0:
+  4:2 CallExpr: call_args (63 -> 23, converged)
+  result:
+  | 	fmt.Println(
+  | 		"aaaaaaaaaaaa",
+  | 		"bbbbbbbbbbbb",
+  | 		"cccccccccccc",
+  | 	)
+
+Stopped: the lines fit in the maximum length after round 0
+`
+
+	assert.Equal(t, expected, stdout.String())
+}
+
+func Test_runner_explain_unformatted(t *testing.T) {
+	root := t.TempDir()
+
+	// The blank lines are collapsed, and the statement is reindented, by the base formatter.
+	writeTestFiles(t, map[string]string{
+		"test.go": `package main
+
+
+
+func main() {
+  fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+`,
+	}, root)
+
+	config := shorten.NewDefaultConfig()
+	config.MaxLen = 40
+
+	runner := NewRunner()
+	runner.shortener = shorten.NewShortener(config)
+	runner.extraFormatter = formatter.NewExecutable("gofmt -s")
+
+	var stdout, stderr bytes.Buffer
+
+	s := newSequencer(1, &stdout, &stderr)
+
+	runner.explain(s, filepath.Join(root, "test.go")+":6")
+	runner.explain(s, filepath.Join(root, "test.go")+":3")
+	runner.explain(s, filepath.Join(root, "test.go")+":20")
+	require.Equal(t, 2, s.GetExitCode())
+
+	expected := filepath.Join(root, "test.go") + `:6: length 63, maximum 40
+(line 4 after the formatting)
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+`
+
+	assert.True(t, strings.HasPrefix(stdout.String(), expected), stdout.String())
+	assert.Contains(t, stdout.String(), "  4:2 CallExpr: call_args (63 -> 23, converged)\n")
+	assert.Equal(t, "line 3 is removed by the base formatter\nline 20 out of range: the file has 7 lines\n", stderr.String())
+}
+
+func Test_parseLocation(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		location string
+		filename string
+		line     int
+		expected string
+	}{
+		{
+			desc:     "valid",
+			location: "dir/file.go:12",
+			filename: "dir/file.go",
+			line:     12,
+		},
+		{
+			desc:     "colon in the path",
+			location: "C:/dir/file.go:3",
+			filename: "C:/dir/file.go",
+			line:     3,
+		},
+		{
+			desc:     "no line",
+			location: "file.go",
+			expected: `invalid location "file.go": expected file.go:line`,
+		},
+		{
+			desc:     "invalid line",
+			location: "file.go:0",
+			expected: `invalid line in location "file.go:0": expected file.go:line`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			filename, line, err := parseLocation(test.location)
+			if test.expected != "" {
+				require.EqualError(t, err, test.expected)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, test.filename, filename)
+			assert.Equal(t, test.line, line)
+		})
+	}
+}
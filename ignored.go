@@ -1,14 +1,16 @@
 package main
 
 import (
+	"go/ast"
 	"go/parser"
 	"go/token"
-	"io/fs"
+	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 
-	"github.com/dave/dst/decorator"
+	"github.com/golangci/golines/internal/ignore"
 )
 
 // Strings to look for to identify generated files.
@@ -25,24 +27,176 @@ var generatedTerms = []string{
 	"automatically regenerated",
 }
 
-func (r *Runner) skipDir(subPath string, f fs.DirEntry) bool {
-	if f.IsDir() {
-		switch f.Name() {
-		case "vendor", "testdata", "node_modules":
-			return true
+// The ignore files read in the walked directories.
+const (
+	golinesIgnoreFile = ".golinesignore"
+	gitIgnoreFile     = ".gitignore"
+)
+
+// fileSelector selects the files to process with gitignore-like patterns.
+// The patterns are matched against the slash-separated paths relative to the current directory
+// (or the absolute paths, outside of the current directory).
+type fileSelector struct {
+	cwd string
+
+	// defaults are the ignored directories and the hidden directories.
+	defaults ignore.Matcher
+
+	// ignoreFiles are the patterns of the ignore files, they take precedence over the defaults.
+	ignoreFiles ignore.Matcher
+
+	// excludes take precedence over the ignore files and the defaults,
+	// e.g., `!testdata/` re-includes the testdata directories.
+	excludes ignore.Matcher
+
+	// includes restrict the files to process if not empty.
+	includes ignore.Matcher
+
+	// gitignore Whether to read the `.gitignore` files in addition to the `.golinesignore` files.
+	gitignore bool
+
+	loaded map[string]bool
+}
+
+func newFileSelector(ignoredDirs, includes, excludes []string, gitignore bool) *fileSelector {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = "."
+	}
+
+	sel := &fileSelector{
+		cwd:       cwd,
+		gitignore: gitignore,
+		loaded:    map[string]bool{},
+	}
+
+	sel.defaults.Add("", ".*/")
+
+	for _, dir := range ignoredDirs {
+		sel.defaults.Add("", dir+"/")
+	}
+
+	sel.excludes.Add("", excludes...)
+	sel.includes.Add("", includes...)
+
+	return sel
+}
+
+// excludeDir excludes a directory (e.g., the output directory) if it's inside the current directory.
+func (sel *fileSelector) excludeDir(dir string) {
+	rel, inside := sel.relPath(dir)
+	if !inside || rel == "." {
+		return
+	}
+
+	sel.excludes.Add("", "/"+rel+"/")
+}
+
+// relPath returns the slash-separated path relative to the current directory,
+// and whether the path is inside the current directory.
+func (sel *fileSelector) relPath(name string) (string, bool) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return filepath.ToSlash(name), false
+	}
+
+	rel, err := filepath.Rel(sel.cwd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(abs), false
+	}
+
+	return filepath.ToSlash(rel), true
+}
+
+// loadIgnoreFiles reads the ignore files of a directory, once.
+func (sel *fileSelector) loadIgnoreFiles(dir string) error {
+	rel, _ := sel.relPath(dir)
+	if sel.loaded[rel] {
+		return nil
+	}
 
-		default:
-			return f.Name() != "." && strings.HasPrefix(f.Name(), ".")
+	sel.loaded[rel] = true
+
+	base := rel
+	if base == "." {
+		base = ""
+	}
+
+	err := sel.ignoreFiles.AddFile(base, filepath.Join(dir, golinesIgnoreFile))
+	if err != nil {
+		return err
+	}
+
+	if !sel.gitignore {
+		return nil
+	}
+
+	return sel.ignoreFiles.AddFile(base, filepath.Join(dir, gitIgnoreFile))
+}
+
+// loadParentIgnoreFiles reads the ignore files of the parent directories of a path,
+// from the current directory.
+func (sel *fileSelector) loadParentIgnoreFiles(name string) error {
+	rel, inside := sel.relPath(name)
+	if !inside {
+		return nil
+	}
+
+	dir := sel.cwd
+
+	err := sel.loadIgnoreFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	parts := strings.Split(rel, "/")
+
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+
+		err = sel.loadIgnoreFiles(dir)
+		if err != nil {
+			return err
 		}
 	}
 
-	if len(r.ignoredDirs) == 0 {
+	return nil
+}
+
+// isExcluded checks whether a path matches the exclusions.
+// The current directory itself is never excluded.
+func (sel *fileSelector) isExcluded(name string, isDir bool) bool {
+	rel, _ := sel.relPath(name)
+	if rel == "." {
 		return false
 	}
 
-	parts := strings.SplitSeq(subPath, "/")
-	for part := range parts {
-		if slices.Contains(r.ignoredDirs, part) {
+	return sel.match(rel, isDir, true)
+}
+
+// isExcludedArg checks whether a path provided in arguments, or one of its parent directories, is excluded.
+// Like gofmt, the files provided in arguments are only checked against the user's patterns
+// (the `--exclude` flag and the ignore files), not against the default ignored directories.
+func (sel *fileSelector) isExcludedArg(name string, isDir bool) bool {
+	rel, inside := sel.relPath(name)
+	if rel == "." {
+		return false
+	}
+
+	defaults := isDir
+
+	if sel.match(rel, isDir, defaults) {
+		return true
+	}
+
+	if !inside {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+
+	for i := 1; i < len(parts); i++ {
+		if sel.match(strings.Join(parts[:i], "/"), true, defaults) {
 			return true
 		}
 	}
@@ -50,24 +204,93 @@ func (r *Runner) skipDir(subPath string, f fs.DirEntry) bool {
 	return false
 }
 
+// match checks a slash-separated relative path against the exclusions, then the ignore files,
+// then (optionally) the defaults.
+func (sel *fileSelector) match(rel string, isDir, defaults bool) bool {
+	if excluded, ok := sel.excludes.Decide(rel, isDir); ok {
+		return excluded
+	}
+
+	if excluded, ok := sel.ignoreFiles.Decide(rel, isDir); ok {
+		return excluded
+	}
+
+	return defaults && sel.defaults.Match(rel, isDir)
+}
+
+// isIncluded checks whether a file matches the inclusions (if any).
+func (sel *fileSelector) isIncluded(name string) bool {
+	if sel.includes.Empty() {
+		return true
+	}
+
+	rel, _ := sel.relPath(name)
+
+	return sel.includes.Match(rel, false)
+}
+
+// skipDir checks whether a walked directory must be skipped:
+// excluded, ignored by the package patterns, or a nested module.
+func (r *Runner) skipDir(path, name string, pattern bool) bool {
+	return r.selector.isExcluded(path, true) ||
+		pattern && skipPatternDir(name) ||
+		r.isModuleBoundary(path)
+}
+
 func (r *Runner) isIgnoredFile(path string) bool {
-	if !strings.HasSuffix(path, ".go") {
+	if !strings.HasSuffix(path, ".go") || !r.selector.isIncluded(path) {
 		return true
 	}
 
-	_, fileName := filepath.Split(path)
+	if r.ignoreGenerated && r.generated.isGeneratedFile(path) {
+		r.stats.addGenerated()
 
-	return r.ignoreGenerated && strings.HasPrefix(fileName, "generated_")
+		return true
+	}
+
+	return false
+}
+
+// Modes of detection of the generated files.
+const (
+	// generatedModeLax matches the header comments containing one of the generatedTerms.
+	generatedModeLax = "lax"
+
+	// generatedModeStrict follows the Go convention, like [ast.IsGenerated].
+	generatedModeStrict = "strict"
+)
+
+// generatedDetector identifies the generated files,
+// by their names or by the comments before the package clause.
+type generatedDetector struct {
+	mode string
+
+	// filePatterns are globs matched against the file names (e.g., `generated_*`).
+	filePatterns []string
+
+	// headerPatterns are matched against each line of the comments before the package clause,
+	// for the generators that don't follow the Go convention.
+	headerPatterns []*regexp.Regexp
+}
+
+// isGeneratedFile checks whether the file name matches one of the file patterns.
+func (d *generatedDetector) isGeneratedFile(path string) bool {
+	fileName := filepath.Base(path)
+
+	return slices.ContainsFunc(d.filePatterns, func(pattern string) bool {
+		matched, err := filepath.Match(pattern, fileName)
+
+		return err == nil && matched
+	})
 }
 
 // isGenerated checks whether the provided file bytes are from a generated file.
-// Note(ldez): it follows a non-conventional notation: it should be changed in the future.
-func isGenerated(content []byte) bool {
+func (d *generatedDetector) isGenerated(content []byte) bool {
 	if len(content) == 0 {
 		return false
 	}
 
-	file, err := decorator.ParseFile(
+	file, err := parser.ParseFile(
 		token.NewFileSet(),
 		"", content,
 		parser.PackageClauseOnly|parser.ParseComments,
@@ -76,14 +299,36 @@ func isGenerated(content []byte) bool {
 		return false
 	}
 
-	decorations := file.Decorations()
+	var header []*ast.Comment
 
-	if decorations == nil {
-		return false
+	for _, group := range file.Comments {
+		if group.Pos() < file.Package {
+			header = append(header, group.List...)
+		}
 	}
 
-	for _, line := range decorations.Start {
-		if len(line) == 0 || !strings.HasPrefix(line, "//") {
+	for _, c := range header {
+		for line := range strings.SplitSeq(c.Text, "\n") {
+			if slices.ContainsFunc(d.headerPatterns, func(pattern *regexp.Regexp) bool {
+				return pattern.MatchString(line)
+			}) {
+				return true
+			}
+		}
+	}
+
+	if d.mode == generatedModeStrict {
+		return ast.IsGenerated(file)
+	}
+
+	return isLaxGenerated(header)
+}
+
+// isLaxGenerated checks whether one of the `//` comments contains one of the generatedTerms.
+// Note(ldez): it follows a non-conventional notation, the strict mode should be preferred.
+func isLaxGenerated(header []*ast.Comment) bool {
+	for _, c := range header {
+		if !strings.HasPrefix(c.Text, "//") {
 			continue
 		}
 
@@ -92,7 +337,7 @@ func isGenerated(content []byte) bool {
 		// instead of the conventional "^// Code generated .* DO NOT EDIT\.$"
 		// https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source
 		for _, term := range generatedTerms {
-			if strings.Contains(strings.ToLower(line), term) {
+			if strings.Contains(strings.ToLower(c.Text), term) {
 				return true
 			}
 		}
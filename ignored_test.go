@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,24 +12,79 @@ import (
 
 func Test_isGenerated(t *testing.T) {
 	testCases := []struct {
-		desc   string
-		file   string
-		assert assert.BoolAssertionFunc
+		desc     string
+		file     string
+		detector *generatedDetector
+		assert   assert.BoolAssertionFunc
 	}{
 		{
-			desc:   "license before comment about generated code",
-			file:   "testdata/generated_license.go",
-			assert: assert.True,
+			desc:     "license before comment about generated code",
+			file:     "testdata/generated_license.go",
+			detector: &generatedDetector{mode: generatedModeLax},
+			assert:   assert.True,
+		},
+		{
+			desc:     "license before comment about generated code (star)",
+			file:     "testdata/generated_license_star.go",
+			detector: &generatedDetector{mode: generatedModeLax},
+			assert:   assert.True,
+		},
+		{
+			desc:     "no generated code comment",
+			file:     "testdata/not_generated.go",
+			detector: &generatedDetector{mode: generatedModeLax},
+			assert:   assert.False,
+		},
+		{
+			desc:     "non-conventional comment",
+			file:     "testdata/generated_nonconventional.go",
+			detector: &generatedDetector{mode: generatedModeLax},
+			assert:   assert.True,
+		},
+		{
+			desc:     "strict: license before comment about generated code",
+			file:     "testdata/generated_license.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.True,
+		},
+		{
+			desc:     "strict: license before comment about generated code (star)",
+			file:     "testdata/generated_license_star.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.True,
+		},
+		{
+			desc:     "strict: no generated code comment",
+			file:     "testdata/not_generated.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.False,
+		},
+		{
+			desc:     "strict: non-conventional comment",
+			file:     "testdata/generated_nonconventional.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.False,
 		},
 		{
-			desc:   "license before comment about generated code (star)",
-			file:   "testdata/generated_license_star.go",
+			desc:     "strict: comment after the package clause",
+			file:     "testdata/generated_after_package.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.False,
+		},
+		{
+			desc: "strict: custom header",
+			file: "testdata/generated_custom.go",
+			detector: &generatedDetector{
+				mode:           generatedModeStrict,
+				headerPatterns: []*regexp.Regexp{regexp.MustCompile(`^// @generated\b`)},
+			},
 			assert: assert.True,
 		},
 		{
-			desc:   "no generated code comment",
-			file:   "testdata/not_generated.go",
-			assert: assert.False,
+			desc:     "strict: custom header without pattern",
+			file:     "testdata/generated_custom.go",
+			detector: &generatedDetector{mode: generatedModeStrict},
+			assert:   assert.False,
 		},
 	}
 
@@ -39,7 +95,50 @@ func Test_isGenerated(t *testing.T) {
 			content, err := os.ReadFile(filepath.FromSlash(test.file))
 			require.NoError(t, err)
 
-			test.assert(t, isGenerated(content))
+			test.assert(t, test.detector.isGenerated(content))
+		})
+	}
+}
+
+func Test_isGeneratedFile(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		path     string
+		patterns []string
+		assert   assert.BoolAssertionFunc
+	}{
+		{
+			desc:     "default pattern",
+			path:     "foo/generated_bar.go",
+			patterns: []string{"generated_*"},
+			assert:   assert.True,
+		},
+		{
+			desc:     "default pattern on a directory",
+			path:     "generated_foo/bar.go",
+			patterns: []string{"generated_*"},
+			assert:   assert.False,
+		},
+		{
+			desc:     "custom patterns",
+			path:     "foo/zz_generated.deepcopy.go",
+			patterns: []string{"generated_*", "zz_generated.*.go"},
+			assert:   assert.True,
+		},
+		{
+			desc:   "no patterns",
+			path:   "foo/generated_bar.go",
+			assert: assert.False,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			detector := &generatedDetector{filePatterns: test.patterns}
+
+			test.assert(t, detector.isGeneratedFile(filepath.FromSlash(test.path)))
 		})
 	}
 }
@@ -3,51 +3,183 @@ package diff
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"path"
+	"path/filepath"
 	"strings"
+)
 
-	rpdiff "github.com/rogpeppe/go-internal/diff"
-	"golang.org/x/term"
+const (
+	ansiGreen   = "\033[92m"
+	ansiRed     = "\033[91m"
+	ansiBlue    = "\033[94m"
+	ansiBold    = "\033[1m"
+	ansiReverse = "\033[7m"
+	ansiNoRev   = "\033[27m"
+	ansiEnd     = "\033[0m"
 )
 
+// Styles of diff.
 const (
-	ansiGreen = "\033[92m"
-	ansiRed   = "\033[91m"
-	ansiBlue  = "\033[94m"
-	ansiEnd   = "\033[0m"
+	// StyleUnified is the unified diff format, the one of `git diff`.
+	StyleUnified = "unified"
+
+	// StyleSideBySide shows the original and the shortened lines in two columns.
+	StyleSideBySide = "side-by-side"
 )
 
-// Pretty prints colored, git-style diffs to the console.
-func Pretty(path string, content, result []byte) []byte {
+// DefaultContext is the default number of context lines around the changes.
+const DefaultContext = 3
+
+// Options are the options of the diffs.
+type Options struct {
+	// Style is the style of the diff: unified or side-by-side (defaults to unified).
+	Style string
+
+	// Color colors the diff with ANSI escape codes,
+	// and highlights the changes within the lines in the unified style.
+	Color bool
+
+	// Context is the number of unchanged lines shown around the changes.
+	Context int
+
+	// Width is the total width of the side-by-side style.
+	Width int
+}
+
+// Pretty returns the diff between the original and the shortened content of a file,
+// in the style defined by the options.
+// The file is labeled `a/path` and `b/path`, like with git.
+func Pretty(path string, content, result []byte, opts Options) []byte {
 	if bytes.Equal(content, result) {
 		return nil
 	}
 
-	patch := rpdiff.Diff(path, content, path+".shortened", result)
+	d := newFileDiff(path, content, result, opts.Context)
+
+	var builder bytes.Buffer
 
-	if !term.IsTerminal(int(os.Stdout.Fd())) {
-		return patch
+	if opts.Style == StyleSideBySide {
+		d.writeSideBySide(&builder, opts)
+	} else {
+		d.writeUnified(&builder, opts.Color)
 	}
 
-	var builder bytes.Buffer
+	return builder.Bytes()
+}
+
+// Unified returns the uncolored, git-style unified diff between the original and the shortened content of a file,
+// that can be applied with `git apply` or `patch -p1`.
+func Unified(path string, content, result []byte, context int) []byte {
+	return Pretty(path, content, result, Options{Style: StyleUnified, Context: context})
+}
+
+// fileDiff is the diff of a file.
+type fileDiff struct {
+	name  string
+	a, b  []string
+	hunks []hunk
+}
+
+func newFileDiff(name string, content, result []byte, context int) *fileDiff {
+	a, b := splitLines(content), splitLines(result)
+
+	return &fileDiff{
+		name:  path.Clean(filepath.ToSlash(name)),
+		a:     a,
+		b:     b,
+		hunks: groupHunks(myers(a, b), max(context, 0)),
+	}
+}
+
+func (d *fileDiff) writeHeader(w *bytes.Buffer, color bool) {
+	header := fmt.Sprintf("diff --git a/%[1]s b/%[1]s\n--- a/%[1]s\n+++ b/%[1]s\n", d.name)
+
+	if !color {
+		w.WriteString(header)
+
+		return
+	}
 
-	for line := range strings.Lines(string(patch)) {
-		switch {
-		case strings.HasPrefix(line, "+"):
-			_, _ = fmt.Fprint(&builder, ansiGreen, line, ansiEnd)
+	for line := range strings.Lines(header) {
+		w.WriteString(ansiBold + strings.TrimSuffix(line, "\n") + ansiEnd + "\n")
+	}
+}
+
+func (d *fileDiff) writeHunkHeader(w *bytes.Buffer, h hunk, color bool) {
+	aStart, aLen, bStart, bLen := h.header()
+
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", aStart, aLen, bStart, bLen)
+
+	if color {
+		header = ansiBlue + header + ansiEnd
+	}
+
+	w.WriteString(header + "\n")
+}
+
+func (d *fileDiff) writeUnified(w *bytes.Buffer, color bool) {
+	d.writeHeader(w, color)
+
+	for _, h := range d.hunks {
+		d.writeHunkHeader(w, h, color)
+
+		var highlights map[op][]span
+		if color {
+			highlights = d.highlights(h)
+		}
+
+		for _, o := range h.ops {
+			var line, lineColor string
 
-		case strings.HasPrefix(line, "-"):
-			_, _ = fmt.Fprint(&builder, ansiRed, line, ansiEnd)
+			switch o.kind {
+			case opEqual:
+				line = d.a[o.a]
 
-		case strings.HasPrefix(line, "^"):
-			_, _ = fmt.Fprint(&builder, ansiBlue, line, ansiEnd)
+			case opDelete:
+				line, lineColor = d.a[o.a], ansiRed
 
-		default:
-			_, _ = fmt.Fprintf(&builder, "%s", line)
+			case opInsert:
+				line, lineColor = d.b[o.b], ansiGreen
+			}
+
+			if !color {
+				lineColor = ""
+			}
+
+			writeLine(w, o.kind, line, lineColor, highlights[o])
 		}
 	}
+}
 
-	_, _ = fmt.Fprintln(&builder)
+// writeLine writes a line of a unified diff,
+// with its color and the highlighted spans (if any).
+func writeLine(w *bytes.Buffer, kind opKind, line, color string, spans []span) {
+	text, hasEOL := strings.CutSuffix(line, "\n")
 
-	return builder.Bytes()
+	if color == "" {
+		w.WriteByte(byte(kind))
+		w.WriteString(text)
+	} else {
+		w.WriteString(color)
+		w.WriteByte(byte(kind))
+		w.WriteString(highlight(text, spans))
+		w.WriteString(ansiEnd)
+	}
+
+	w.WriteByte('\n')
+
+	if !hasEOL {
+		w.WriteString("\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits a content in lines, including their line feeds.
+func splitLines(content []byte) []string {
+	var lines []string
+
+	for line := range strings.Lines(string(content)) {
+		lines = append(lines, line)
+	}
+
+	return lines
 }
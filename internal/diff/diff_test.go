@@ -1,6 +1,10 @@
 package diff
 
 import (
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,15 +15,17 @@ func TestPretty(t *testing.T) {
 		desc     string
 		content  string
 		result   string
+		opts     Options
 		expected string
 	}{
 		{
 			desc:    "simple diff",
 			content: "line 1\nline 2\n",
 			result:  "line 1\nline 2 modified\n",
-			expected: `diff example.txt example.txt.shortened
---- example.txt
-+++ example.txt.shortened
+			opts:    Options{Context: DefaultContext},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
 @@ -1,2 +1,2 @@
  line 1
 -line 2
@@ -30,17 +36,316 @@ func TestPretty(t *testing.T) {
 			desc:     "no diff",
 			content:  "line 1\nline 2",
 			result:   "line 1\nline 2",
+			opts:     Options{Context: DefaultContext},
 			expected: "",
 		},
+		{
+			desc:    "no context",
+			content: "a\nb\nc\nd\n",
+			result:  "a\nB\nc\nd\n",
+			opts:    Options{},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -2,1 +2,1 @@
+-b
++B
+`,
+		},
+		{
+			desc:    "separate hunks",
+			content: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			result:  "1\n2a\n3\n4\n5\n6\n7b\n8\n",
+			opts:    Options{Context: 1},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -1,3 +1,3 @@
+ 1
+-2
++2a
+ 3
+@@ -6,3 +6,3 @@
+ 6
+-7
++7b
+ 8
+`,
+		},
+		{
+			desc:    "merged hunks",
+			content: "1\n2\n3\n4\n5\n6\n",
+			result:  "1\n2a\n3\n4\n5b\n6\n",
+			opts:    Options{Context: 1},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -1,6 +1,6 @@
+ 1
+-2
++2a
+ 3
+ 4
+-5
++5b
+ 6
+`,
+		},
+		{
+			desc:    "insertion only",
+			content: "a\nb\n",
+			result:  "a\nx\nb\n",
+			opts:    Options{},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -1,0 +2,1 @@
++x
+`,
+		},
+		{
+			desc:    "no newline at end of file",
+			content: "a\nb",
+			result:  "a\nb\n",
+			opts:    Options{Context: DefaultContext},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`,
+		},
+		{
+			desc:    "side by side",
+			content: "a\nfoo(x, y)\nb\n",
+			result:  "a\nfoo(\n\tx,\n\ty,\n)\nb\n",
+			opts:    Options{Style: StyleSideBySide, Context: DefaultContext, Width: 23},
+			expected: `diff --git a/example.txt b/example.txt
+--- a/example.txt
++++ b/example.txt
+@@ -1,3 +1,6 @@
+a            a
+foo(x, y)  | foo(
+           >     x,
+           >     y,
+           > )
+b            b
+`,
+		},
+		{
+			desc:    "highlighted changes",
+			content: "foo(x, y)\n",
+			result:  "foo(\n\tx,\n\ty,\n)\n",
+			opts:    Options{Color: true},
+			expected: ansiBold + "diff --git a/example.txt b/example.txt" + ansiEnd + "\n" +
+				ansiBold + "--- a/example.txt" + ansiEnd + "\n" +
+				ansiBold + "+++ b/example.txt" + ansiEnd + "\n" +
+				ansiBlue + "@@ -1,1 +1,4 @@" + ansiEnd + "\n" +
+				ansiRed + "-foo(x, y)" + ansiEnd + "\n" +
+				ansiGreen + "+foo(" + ansiEnd + "\n" +
+				ansiGreen + "+\tx," + ansiEnd + "\n" +
+				ansiGreen + "+\ty" + ansiReverse + "," + ansiNoRev + ansiEnd + "\n" +
+				ansiGreen + "+)" + ansiEnd + "\n",
+		},
 	}
 
 	for _, test := range testCases {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			output := Pretty("example.txt", []byte(test.content), []byte(test.result))
+			output := Pretty("example.txt", []byte(test.content), []byte(test.result), test.opts)
 
 			assert.Equal(t, test.expected, string(output))
 		})
 	}
 }
+
+func Test_myers(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+
+	randomLines := func() []string {
+		lines := make([]string, rnd.IntN(20))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.IntN(4)))
+		}
+
+		return lines
+	}
+
+	for range 500 {
+		a, b := randomLines(), randomLines()
+
+		var (
+			reconstructed []string
+			nbEdits       int
+		)
+
+		ops := myers(a, b)
+
+		for _, o := range ops {
+			if o.kind != opEqual {
+				nbEdits++
+			}
+
+			switch o.kind {
+			case opEqual:
+				assert.Equal(t, a[o.a], b[o.b])
+
+				reconstructed = append(reconstructed, b[o.b])
+
+			case opInsert:
+				reconstructed = append(reconstructed, b[o.b])
+
+			case opDelete:
+			}
+		}
+
+		assert.Equal(t, strings.Join(b, ""), strings.Join(reconstructed, ""), "%v -> %v", a, b)
+		assert.Equal(t, len(a)+len(b)-2*lcsLen(a, b), nbEdits, "%v -> %v", a, b)
+	}
+}
+
+func Test_myers_size(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		size     int
+		change   func(i int, a []string) string
+		expected int
+	}{
+		{
+			desc:     "modified lines",
+			size:     20000,
+			change:   func(i int, a []string) string { return a[i] + " modified" },
+			expected: 30000,
+		},
+		{
+			// The changed lines have matches in the other sequence: they are not discarded.
+			desc:     "swapped lines",
+			size:     4000,
+			change:   func(i int, a []string) string { return a[i^1] },
+			expected: 6000,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			a := make([]string, test.size)
+			for i := range a {
+				a[i] = strconv.Itoa(i)
+			}
+
+			// Every other line is changed.
+			b := slices.Clone(a)
+			for i := 0; i < len(b); i += 2 {
+				b[i] = test.change(i, a)
+			}
+
+			assert.Len(t, myers(a, b), test.expected)
+
+			// The memory is linear in the size of the input, not quadratic in the number of edits:
+			// the diagonals are allocated once, and there is at most one operation per element.
+			ra, _ := matchable(a, b)
+			rb, _ := matchable(b, a)
+
+			d := newDiffer(ra, rb)
+			d.compare(0, len(ra), 0, len(rb))
+
+			size := len(ra) + len(rb)
+
+			assert.Len(t, d.forward, 2*size+3)
+			assert.Len(t, d.reverse, 2*size+3)
+			assert.LessOrEqual(t, len(d.ops), size)
+		})
+	}
+}
+
+// lcsLen returns the length of the longest common subsequence of two sequences.
+func lcsLen(a, b []string) int {
+	prev, curr := make([]int, len(b)+1), make([]int, len(b)+1)
+
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				curr[j+1] = prev[j] + 1
+			} else {
+				curr[j+1] = max(prev[j+1], curr[j])
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func TestMapLines(t *testing.T) {
+	content := "package main\n\nfunc main() {\n  a()\n  f(aaa, bbb)\n}\n"
+	result := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\ta()\n\tf(\n\t\taaa,\n\t\tbbb,\n\t)\n}\n"
+
+	expected := []int{
+		0, 1,
+		// The inserted lines are mapped to the next line.
+		2, 2,
+		2,
+		// The changed lines are mapped one by one, then to the last replaced line.
+		3, 4, 4, 4, 4,
+		5,
+	}
+
+	assert.Equal(t, expected, MapLines([]byte(content), []byte(result)))
+}
+
+func TestMapLine(t *testing.T) {
+	content := "package main\n\n\nfunc main() {\n  a()\n  f(aaa, bbb)\n}\n"
+	result := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\ta()\n\tf(\n\t\taaa,\n\t\tbbb,\n\t)\n}\n"
+
+	testCases := []struct {
+		desc     string
+		line     int
+		expected int
+		found    bool
+	}{
+		{
+			desc:     "unchanged line",
+			line:     3,
+			expected: 4,
+			found:    true,
+		},
+		{
+			desc:     "reindented line",
+			line:     4,
+			expected: 5,
+			found:    true,
+		},
+		{
+			desc:     "split line",
+			line:     5,
+			expected: 6,
+			found:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			line, found := MapLine([]byte(content), []byte(result), test.line)
+
+			assert.Equal(t, test.expected, line)
+			assert.Equal(t, test.found, found)
+		})
+	}
+
+	t.Run("removed line", func(t *testing.T) {
+		t.Parallel()
+
+		_, found := MapLine([]byte("a\nb\nc\n"), []byte("a\nc\n"), 1)
+
+		assert.False(t, found)
+	})
+}
@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// span is a range of bytes of a line to highlight.
+type span struct {
+	start, end int
+}
+
+// token is a word or a punctuation character of a changed line.
+type token struct {
+	op         op
+	start, end int
+	text       string
+}
+
+// highlights computes the spans to highlight in the changed lines of a hunk.
+//
+// The tokens of each run of deleted lines are compared to the ones of the inserted lines that replace them,
+// ignoring the spaces and the line breaks: when a long line is only split,
+// the highlighted spans are the tokens that moved or changed, like the added trailing commas.
+func (d *fileDiff) highlights(h hunk) map[op][]span {
+	result := map[op][]span{}
+
+	for i := 0; i < len(h.ops); {
+		if h.ops[i].kind == opEqual {
+			i++
+
+			continue
+		}
+
+		var deleted, inserted []token
+
+		for ; i < len(h.ops) && h.ops[i].kind != opEqual; i++ {
+			o := h.ops[i]
+
+			if o.kind == opDelete {
+				deleted = append(deleted, tokenize(o, d.a[o.a])...)
+			} else {
+				inserted = append(inserted, tokenize(o, d.b[o.b])...)
+			}
+		}
+
+		// The lines only deleted or only inserted are already colored as a whole.
+		if len(deleted) == 0 || len(inserted) == 0 {
+			continue
+		}
+
+		for _, e := range myers(texts(deleted), texts(inserted)) {
+			var t token
+
+			switch e.kind {
+			case opDelete:
+				t = deleted[e.a]
+
+			case opInsert:
+				t = inserted[e.b]
+
+			default:
+				continue
+			}
+
+			spans := result[t.op]
+
+			// Merges the adjacent tokens.
+			if len(spans) > 0 && spans[len(spans)-1].end == t.start {
+				spans[len(spans)-1].end = t.end
+			} else {
+				spans = append(spans, span{start: t.start, end: t.end})
+			}
+
+			result[t.op] = spans
+		}
+	}
+
+	return result
+}
+
+// tokenize splits a line in words (letters, digits, and underscores) and single punctuation characters.
+// The spaces are skipped.
+func tokenize(o op, line string) []token {
+	line = strings.TrimSuffix(line, "\n")
+
+	var tokens []token
+
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+
+		case isWordRune(r):
+			end := i + size
+
+			for end < len(line) {
+				r, size := utf8.DecodeRuneInString(line[end:])
+				if !isWordRune(r) {
+					break
+				}
+
+				end += size
+			}
+
+			tokens = append(tokens, token{op: o, start: i, end: end, text: line[i:end]})
+			i = end
+
+		default:
+			tokens = append(tokens, token{op: o, start: i, end: i + size, text: line[i : i+size]})
+			i += size
+		}
+	}
+
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func texts(tokens []token) []string {
+	result := make([]string, len(tokens))
+
+	for i, t := range tokens {
+		result[i] = t.text
+	}
+
+	return result
+}
+
+// highlight wraps the spans of a line in reverse video escape codes.
+func highlight(text string, spans []span) string {
+	if len(spans) == 0 {
+		return text
+	}
+
+	var builder strings.Builder
+
+	last := 0
+
+	for _, s := range spans {
+		builder.WriteString(text[last:s.start])
+		builder.WriteString(ansiReverse)
+		builder.WriteString(text[s.start:s.end])
+		builder.WriteString(ansiNoRev)
+
+		last = s.end
+	}
+
+	builder.WriteString(text[last:])
+
+	return builder.String()
+}
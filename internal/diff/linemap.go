@@ -0,0 +1,77 @@
+package diff
+
+import "strings"
+
+// MapLines returns, for each line of the result, the (0-based) index of the corresponding line of the content.
+//
+// The unchanged lines are mapped to themselves.
+// The lines of a changed block are mapped one by one to the lines they replace,
+// the extra lines to the last replaced line (e.g., a statement split on several lines),
+// and the inserted lines (e.g., an added import) to the next line of the content.
+func MapLines(content, result []byte) []int {
+	a, b := splitLines(content), splitLines(result)
+
+	lines := make([]int, 0, len(b))
+
+	ops := deletionsFirst(myers(a, b))
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			lines = append(lines, ops[i].a)
+			i++
+
+			continue
+		}
+
+		start := ops[i].a
+
+		var nbDeleted, nbInserted int
+
+		for ; i < len(ops) && ops[i].kind != opEqual; i++ {
+			if ops[i].kind == opDelete {
+				nbDeleted++
+
+				continue
+			}
+
+			if nbDeleted == 0 {
+				lines = append(lines, min(start, max(len(a)-1, 0)))
+			} else {
+				lines = append(lines, start+min(nbInserted, nbDeleted-1))
+			}
+
+			nbInserted++
+		}
+	}
+
+	return lines
+}
+
+// MapLine returns the (0-based) index of the line of the result corresponding to a line of the content,
+// i.e., the reverse of MapLines: an unchanged (or only reindented) line is preferred,
+// otherwise the first line of the result that replaces it.
+// It returns false if the line is removed from the result (e.g., a collapsed blank line).
+func MapLine(content, result []byte, line int) (int, bool) {
+	a, b := splitLines(content), splitLines(result)
+	if line < 0 || line >= len(a) {
+		return -1, false
+	}
+
+	candidate := -1
+
+	for i, original := range MapLines(content, result) {
+		if original != line {
+			continue
+		}
+
+		if strings.TrimSpace(b[i]) == strings.TrimSpace(a[line]) {
+			return i, true
+		}
+
+		if candidate < 0 {
+			candidate = i
+		}
+	}
+
+	return candidate, candidate >= 0
+}
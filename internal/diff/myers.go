@@ -0,0 +1,346 @@
+package diff
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+// op is an edit operation of a diff.
+// a and b are the positions in the old and new sequences:
+// the index of the element for the equal and deleted (a), and the equal and inserted (b) elements,
+// the insertion (or deletion) point otherwise.
+type op struct {
+	kind opKind
+	a, b int
+}
+
+// myers computes the shortest edit script between two sequences with the linear space variant
+// of the Myers algorithm: the middle snake of the edit path is found from both ends,
+// then the sequences before and after it are compared recursively.
+// The time is O((N+M)D) and the memory O(N+M), where D is the number of edits.
+//
+// The elements without any match in the other sequence are edits in every script:
+// like GNU diff, they are discarded before the comparison, which makes it much faster
+// when most of the changed lines are unique (e.g., shortened lines).
+//
+// http://www.xmailserver.org/diff2.pdf
+func myers[T comparable](a, b []T) []op {
+	ra, aIndexes := matchable(a, b)
+	rb, bIndexes := matchable(b, a)
+
+	d := newDiffer(ra, rb)
+
+	d.compare(0, len(ra), 0, len(rb))
+
+	// Rebuild the script of the whole sequences from the matched elements.
+	ops := make([]op, 0, len(a)+len(b)-len(ra)-len(rb)+len(d.ops))
+
+	var i, j int
+
+	appendEdits := func(aEnd, bEnd int) {
+		for ; i < aEnd; i++ {
+			ops = append(ops, op{kind: opDelete, a: i, b: j})
+		}
+
+		for ; j < bEnd; j++ {
+			ops = append(ops, op{kind: opInsert, a: i, b: j})
+		}
+	}
+
+	for _, o := range d.ops {
+		if o.kind != opEqual {
+			continue
+		}
+
+		appendEdits(aIndexes[o.a], bIndexes[o.b])
+
+		ops = append(ops, op{kind: opEqual, a: i, b: j})
+		i++
+		j++
+	}
+
+	appendEdits(len(a), len(b))
+
+	return ops
+}
+
+// matchable returns the elements of a sequence that are in the other one, and their indexes.
+func matchable[T comparable](seq, other []T) ([]T, []int) {
+	set := make(map[T]struct{}, len(other))
+	for _, e := range other {
+		set[e] = struct{}{}
+	}
+
+	var (
+		elements []T
+		indexes  []int
+	)
+
+	for i, e := range seq {
+		if _, ok := set[e]; ok {
+			elements = append(elements, e)
+			indexes = append(indexes, i)
+		}
+	}
+
+	return elements, indexes
+}
+
+// differ holds the state of a diff computation.
+type differ[T comparable] struct {
+	a, b []T
+
+	// forward and reverse are the furthest reaching x positions on each diagonal,
+	// from the start and from the end of the compared ranges.
+	// They are shared by all the recursive comparisons.
+	forward, reverse []int
+
+	ops []op
+}
+
+// newDiffer returns a differ of two sequences, with the diagonals of all the comparisons.
+func newDiffer[T comparable](a, b []T) *differ[T] {
+	size := 2*(len(a)+len(b)) + 3
+
+	return &differ[T]{
+		a:       a,
+		b:       b,
+		forward: make([]int, size),
+		reverse: make([]int, size),
+	}
+}
+
+// compare appends the edit script of a[aLo:aHi] and b[bLo:bHi].
+func (d *differ[T]) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, op{kind: opEqual, a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.ops = append(d.ops, op{kind: opInsert, a: aLo, b: j})
+		}
+
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.ops = append(d.ops, op{kind: opDelete, a: i, b: bLo})
+		}
+
+	default:
+		// Without common prefix and suffix, and with two non-empty ranges, there are at least 2 edits:
+		// the middle snake splits them in two smaller problems.
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+
+		d.compare(aLo, x, bLo, y)
+
+		for i := range u - x {
+			d.ops = append(d.ops, op{kind: opEqual, a: x + i, b: y + i})
+		}
+
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := range suffix {
+		d.ops = append(d.ops, op{kind: opEqual, a: aHi + i, b: bHi + i})
+	}
+}
+
+// middleSnake finds the middle snake of the shortest edit path of a[aLo:aHi] and b[bLo:bHi]:
+// the diagonal (possibly empty) from (x, y) to (u, v) where the paths from both ends meet.
+func (d *differ[T]) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+
+	// The diagonals k = x - y are in [-(n+m), n+m], with x and y relative to aLo and bLo.
+	// The reverse search runs on the reversed ranges: its diagonal k is the diagonal delta-k of the forward search.
+	offset := n + m + 1
+
+	vf, vr := d.forward, d.reverse
+	vf[offset+1] = 0
+	vr[offset+1] = 0
+
+	for e := 0; e <= (n+m+1)/2; e++ {
+		for k := -e; k <= e; k += 2 {
+			var sx int
+
+			if k == -e || k != e && vf[offset+k-1] < vf[offset+k+1] {
+				sx = vf[offset+k+1]
+			} else {
+				sx = vf[offset+k-1] + 1
+			}
+
+			sy := sx - k
+			ex, ey := sx, sy
+
+			for ex < n && ey < m && d.a[aLo+ex] == d.b[bLo+ey] {
+				ex++
+				ey++
+			}
+
+			vf[offset+k] = ex
+
+			if rk := delta - k; odd && rk >= -(e-1) && rk <= e-1 && ex >= n-vr[offset+rk] {
+				return aLo + sx, bLo + sy, aLo + ex, bLo + ey
+			}
+		}
+
+		for k := -e; k <= e; k += 2 {
+			var sx int
+
+			if k == -e || k != e && vr[offset+k-1] < vr[offset+k+1] {
+				sx = vr[offset+k+1]
+			} else {
+				sx = vr[offset+k-1] + 1
+			}
+
+			sy := sx - k
+			ex, ey := sx, sy
+
+			for ex < n && ey < m && d.a[aHi-ex-1] == d.b[bHi-ey-1] {
+				ex++
+				ey++
+			}
+
+			vr[offset+k] = ex
+
+			if fk := delta - k; !odd && fk >= -e && fk <= e && vf[offset+fk] >= n-ex {
+				return aHi - ex, bHi - ey, aHi - sx, bHi - sy
+			}
+		}
+	}
+
+	// Unreachable: the paths always meet.
+	panic("diff: no middle snake")
+}
+
+// hunk is a group of changes with their context.
+type hunk struct {
+	ops []op
+}
+
+// header returns the unified diff header of the hunk, e.g., `@@ -1,3 +1,4 @@`.
+// An empty range starts at the line before it.
+func (h hunk) header() (aStart, aLen, bStart, bLen int) {
+	aStart, bStart = h.ops[0].a, h.ops[0].b
+
+	for _, o := range h.ops {
+		if o.kind != opInsert {
+			aLen++
+		}
+
+		if o.kind != opDelete {
+			bLen++
+		}
+	}
+
+	if aLen > 0 {
+		aStart++
+	}
+
+	if bLen > 0 {
+		bStart++
+	}
+
+	return aStart, aLen, bStart, bLen
+}
+
+// groupHunks groups the changes in hunks, with the given number of context lines around them:
+// the changes separated by at most twice this number of lines are in the same hunk.
+func groupHunks(ops []op, context int) []hunk {
+	ops = deletionsFirst(ops)
+
+	var hunks []hunk
+
+	for i := 0; i < len(ops); {
+		// Find the next change.
+		for i < len(ops) && ops[i].kind == opEqual {
+			i++
+		}
+
+		if i == len(ops) {
+			break
+		}
+
+		start := max(0, i-context)
+		end := i
+
+		for {
+			for end < len(ops) && ops[end].kind != opEqual {
+				end++
+			}
+
+			next := end
+			for next < len(ops) && ops[next].kind == opEqual {
+				next++
+			}
+
+			if next < len(ops) && next-end <= 2*context {
+				end = next
+
+				continue
+			}
+
+			end = min(len(ops), end+context)
+
+			break
+		}
+
+		hunks = append(hunks, hunk{ops: ops[start:end]})
+
+		i = end
+	}
+
+	return hunks
+}
+
+// deletionsFirst reorders each run of changes so that the deletions come before the insertions (like git),
+// and updates their positions.
+func deletionsFirst(ops []op) []op {
+	result := make([]op, 0, len(ops))
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			result = append(result, ops[i])
+			i++
+
+			continue
+		}
+
+		a0, b0 := ops[i].a, ops[i].b
+
+		var nbDeleted, nbInserted int
+
+		for ; i < len(ops) && ops[i].kind != opEqual; i++ {
+			if ops[i].kind == opDelete {
+				nbDeleted++
+			} else {
+				nbInserted++
+			}
+		}
+
+		for j := range nbDeleted {
+			result = append(result, op{kind: opDelete, a: a0 + j, b: b0})
+		}
+
+		for j := range nbInserted {
+			result = append(result, op{kind: opInsert, a: a0 + nbDeleted, b: b0 + j})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultWidth is the default total width of the side-by-side style.
+const DefaultWidth = 160
+
+// sideBySideTabLen is the number of spaces replacing a tab in the side-by-side style, to keep the columns aligned.
+const sideBySideTabLen = 4
+
+// writeSideBySide writes the hunks in two columns, like `sdiff`:
+// the original lines on the left, the shortened ones on the right,
+// and a marker between them (`|` changed, `<` deleted, `>` inserted).
+func (d *fileDiff) writeSideBySide(w *bytes.Buffer, opts Options) {
+	width := opts.Width
+	if width <= 0 {
+		width = DefaultWidth
+	}
+
+	column := max((width-3)/2, 1)
+
+	d.writeHeader(w, opts.Color)
+
+	for _, h := range d.hunks {
+		d.writeHunkHeader(w, h, opts.Color)
+
+		for i := 0; i < len(h.ops); {
+			if h.ops[i].kind == opEqual {
+				line := d.a[h.ops[i].a]
+				writeRow(w, column, line, ' ', line, "", "")
+				i++
+
+				continue
+			}
+
+			var deleted, inserted []string
+
+			for ; i < len(h.ops) && h.ops[i].kind != opEqual; i++ {
+				o := h.ops[i]
+
+				if o.kind == opDelete {
+					deleted = append(deleted, d.a[o.a])
+				} else {
+					inserted = append(inserted, d.b[o.b])
+				}
+			}
+
+			var leftColor, rightColor string
+			if opts.Color {
+				leftColor, rightColor = ansiRed, ansiGreen
+			}
+
+			for j := range max(len(deleted), len(inserted)) {
+				var left, right string
+
+				marker := byte('|')
+
+				switch {
+				case j >= len(inserted):
+					left, marker = deleted[j], '<'
+
+				case j >= len(deleted):
+					right, marker = inserted[j], '>'
+
+				default:
+					left, right = deleted[j], inserted[j]
+				}
+
+				writeRow(w, column, left, marker, right, leftColor, rightColor)
+			}
+		}
+	}
+}
+
+// writeRow writes a row of the side-by-side style.
+func writeRow(w *bytes.Buffer, column int, left string, marker byte, right, leftColor, rightColor string) {
+	w.WriteString(colorize(fit(left, column, true), leftColor))
+	w.WriteByte(' ')
+	w.WriteByte(marker)
+	w.WriteByte(' ')
+	w.WriteString(colorize(fit(right, column, false), rightColor))
+	w.WriteByte('\n')
+}
+
+// fit expands the tabs of a line, and truncates it to the width of a column.
+// The left column is also padded with spaces.
+func fit(line string, column int, pad bool) string {
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.ReplaceAll(line, "\t", strings.Repeat(" ", sideBySideTabLen))
+
+	if utf8.RuneCountInString(line) > column {
+		line = string([]rune(line)[:column])
+	}
+
+	if pad {
+		line += strings.Repeat(" ", column-utf8.RuneCountInString(line))
+	}
+
+	return line
+}
+
+func colorize(text, color string) string {
+	if color == "" || strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	return color + text + ansiEnd
+}
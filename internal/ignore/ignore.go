@@ -0,0 +1,175 @@
+// Package ignore matches slash-separated paths against gitignore-like patterns.
+//
+// https://git-scm.com/docs/gitignore#_pattern_format
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Pattern is a single gitignore-like pattern.
+type Pattern struct {
+	// base is the directory of the file defining the pattern (empty for the root).
+	base string
+
+	segments []string
+
+	negate  bool
+	dirOnly bool
+}
+
+// ParsePattern parses a gitignore-like pattern, relative to the base directory.
+// It returns false for the blank lines and the comments.
+func ParsePattern(base, line string) (*Pattern, bool) {
+	line = strings.TrimRight(line, " \t\r")
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	p := &Pattern{base: strings.Trim(base, "/")}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	}
+
+	// A leading backslash escapes a hash or an exclamation mark.
+	line = strings.TrimPrefix(line, `\`)
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return nil, false
+	}
+
+	// A pattern without a slash (except the trailing one) matches at any level.
+	if !strings.Contains(line, "/") {
+		line = "**/" + line
+	}
+
+	p.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+
+	return p, true
+}
+
+// Match reports whether the pattern matches the slash-separated path.
+// The path is relative to the same root as the base of the pattern.
+func (p *Pattern) Match(name string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	name = strings.Trim(name, "/")
+
+	if p.base != "" {
+		rel, ok := strings.CutPrefix(name, p.base+"/")
+		if !ok {
+			return false
+		}
+
+		name = rel
+	}
+
+	return matchSegments(p.segments, strings.Split(name, "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments:
+// `**` matches zero or more segments, except at the end where it matches at least one.
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		if len(patterns) == 1 {
+			return len(segments) > 0
+		}
+
+		for i := range len(segments) + 1 {
+			if matchSegments(patterns[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(patterns[0], segments[0])
+
+	return err == nil && matched && matchSegments(patterns[1:], segments[1:])
+}
+
+// Matcher is an ordered list of patterns: the last matching pattern wins.
+type Matcher struct {
+	patterns []*Pattern
+}
+
+// Add adds patterns relative to the base directory.
+func (m *Matcher) Add(base string, lines ...string) {
+	for _, line := range lines {
+		if p, ok := ParsePattern(base, line); ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+}
+
+// AddFile adds the patterns of an ignore file (e.g., `.gitignore`) located in the base directory.
+// A missing file is not an error.
+func (m *Matcher) AddFile(base, filename string) error {
+	file, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		m.Add(base, scanner.Text())
+	}
+
+	return scanner.Err()
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Match reports whether the path matches the patterns,
+// i.e., whether the last matching pattern is not a negation.
+func (m *Matcher) Match(name string, isDir bool) bool {
+	matched, _ := m.Decide(name, isDir)
+
+	return matched
+}
+
+// Decide is like Match, but it also reports whether a pattern (possibly a negation) matched the path.
+// It's used to give precedence to a matcher over another one.
+func (m *Matcher) Decide(name string, isDir bool) (matched, decided bool) {
+	for i := len(m.patterns) - 1; i >= 0; i-- {
+		if m.patterns[i].Match(name, isDir) {
+			return !m.patterns[i].negate, true
+		}
+	}
+
+	return false, false
+}
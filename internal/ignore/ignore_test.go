@@ -0,0 +1,200 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern_Match(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		base    string
+		pattern string
+		name    string
+		isDir   bool
+		assert  assert.BoolAssertionFunc
+	}{
+		{
+			desc:    "name at root",
+			pattern: "vendor",
+			name:    "vendor",
+			isDir:   true,
+			assert:  assert.True,
+		},
+		{
+			desc:    "name at any level",
+			pattern: "vendor",
+			name:    "a/b/vendor",
+			isDir:   true,
+			assert:  assert.True,
+		},
+		{
+			desc:    "name is not a prefix",
+			pattern: "vendor",
+			name:    "vendors",
+			isDir:   true,
+			assert:  assert.False,
+		},
+		{
+			desc:    "directory only on a directory",
+			pattern: "testdata/",
+			name:    "pkg/testdata",
+			isDir:   true,
+			assert:  assert.True,
+		},
+		{
+			desc:    "directory only on a file",
+			pattern: "testdata/",
+			name:    "pkg/testdata",
+			assert:  assert.False,
+		},
+		{
+			desc:    "glob at any level",
+			pattern: "*.pb.go",
+			name:    "api/v1/service.pb.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "anchored",
+			pattern: "/gen",
+			name:    "gen",
+			isDir:   true,
+			assert:  assert.True,
+		},
+		{
+			desc:    "anchored in a sub-directory",
+			pattern: "/gen",
+			name:    "pkg/gen",
+			isDir:   true,
+			assert:  assert.False,
+		},
+		{
+			desc:    "anchored by a middle slash",
+			pattern: "pkg/*.go",
+			name:    "pkg/a.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "anchored by a middle slash in a sub-directory",
+			pattern: "pkg/*.go",
+			name:    "sub/pkg/a.go",
+			assert:  assert.False,
+		},
+		{
+			desc:    "single star doesn't cross directories",
+			pattern: "pkg/*.go",
+			name:    "pkg/sub/a.go",
+			assert:  assert.False,
+		},
+		{
+			desc:    "leading double star",
+			pattern: "**/mocks/*.go",
+			name:    "a/b/mocks/m.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "middle double star matches zero directories",
+			pattern: "pkg/**/*.go",
+			name:    "pkg/a.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "middle double star",
+			pattern: "pkg/**/*.go",
+			name:    "pkg/a/b/c.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "trailing double star",
+			pattern: "pkg/**",
+			name:    "pkg/a/b.go",
+			assert:  assert.True,
+		},
+		{
+			desc:    "trailing double star doesn't match the directory itself",
+			pattern: "pkg/**",
+			name:    "pkg",
+			isDir:   true,
+			assert:  assert.False,
+		},
+		{
+			desc:    "base directory",
+			base:    "pkg",
+			pattern: "/gen",
+			name:    "pkg/gen",
+			isDir:   true,
+			assert:  assert.True,
+		},
+		{
+			desc:    "outside of the base directory",
+			base:    "pkg",
+			pattern: "gen",
+			name:    "gen",
+			isDir:   true,
+			assert:  assert.False,
+		},
+		{
+			desc:    "escaped hash",
+			pattern: `\#file.go`,
+			name:    "#file.go",
+			assert:  assert.True,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p, ok := ParsePattern(test.base, test.pattern)
+			require.True(t, ok)
+
+			test.assert(t, p.Match(test.name, test.isDir))
+		})
+	}
+}
+
+func TestParsePattern_skipped(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "/", "!"} {
+		_, ok := ParsePattern("", line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestMatcher_Match(t *testing.T) {
+	var m Matcher
+
+	m.Add("", "testdata/", ".*/", "*_gen.go", "!keep_gen.go")
+
+	assert.True(t, m.Match("pkg/testdata", true))
+	assert.True(t, m.Match(".git", true))
+	assert.True(t, m.Match("pkg/a_gen.go", false))
+	assert.False(t, m.Match("pkg/keep_gen.go", false))
+	assert.False(t, m.Match("pkg/a.go", false))
+
+	// The last matching pattern wins.
+	m.Add("", "!testdata/")
+
+	assert.False(t, m.Match("pkg/testdata", true))
+}
+
+func TestMatcher_AddFile(t *testing.T) {
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, ".golinesignore"), []byte("# generated\n/gen/\n*.pb.go\n"), 0o644)
+	require.NoError(t, err)
+
+	var m Matcher
+
+	require.NoError(t, m.AddFile("sub", filepath.Join(dir, ".golinesignore")))
+	require.NoError(t, m.AddFile("sub", filepath.Join(dir, ".gitignore")))
+
+	assert.False(t, m.Empty())
+	assert.True(t, m.Match("sub/gen", true))
+	assert.False(t, m.Match("sub/pkg/gen", true))
+	assert.True(t, m.Match("sub/pkg/a.pb.go", false))
+	assert.False(t, m.Match("other/a.pb.go", false))
+}
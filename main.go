@@ -1,7 +1,9 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
@@ -12,6 +14,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/golangci/golines/internal/diff"
@@ -29,6 +34,10 @@ var (
 
 var (
 	// Flags.
+	backupSuffix = kingpin.Flag(
+		"backup",
+		"Keep the original of each written file, with this suffix (e.g. '.orig'); "+
+			"the backups can be put back with the restore command").PlaceHolder("<suffix>").String()
 	baseFormatterCmd = kingpin.Flag(
 		"base-formatter",
 		"Base formatter to use").Default("").String()
@@ -36,42 +45,182 @@ var (
 		"chain-split-dots",
 		"Split chained methods on the dots as opposed to the arguments").
 		Default("true").Bool()
+	checkIdempotency = kingpin.Flag(
+		"check-idempotency",
+		"Process the output of each file a second time and report the differences, "+
+			"and report the shortening rounds that don't converge as errors").Default("false").Bool()
+	colorMode = kingpin.Flag(
+		"color",
+		"When to color the diffs: auto (when the output is a terminal and NO_COLOR is not set), always, or never").
+		Default(colorAuto).Enum(colorAuto, colorAlways, colorNever)
 	debugFlag = kingpin.Flag(
 		"debug",
 		"Show debug output").Short('d').Default("false").Bool()
+	diffContext = kingpin.Flag(
+		"diff-context",
+		"Number of unchanged lines shown around the changes in the diffs").
+		Default(strconv.Itoa(diff.DefaultContext)).Int()
+	diffStyle = kingpin.Flag(
+		"diff-style",
+		"Style of the diffs shown with --dry-run: unified, or side-by-side").
+		Default(diff.StyleUnified).Enum(diff.StyleUnified, diff.StyleSideBySide)
 	dotFile = kingpin.Flag(
 		"dot-file",
-		"Path to dot representation of the AST graph").Default("").String()
+		"Path to dot representation of the AST graph (see --graph-format)").Default("").String()
 	dryRun = kingpin.Flag(
 		"dry-run",
 		"Show diffs without writing anything").Default("false").Bool()
+	excludes = kingpin.Flag(
+		"exclude",
+		"Gitignore-like pattern of the paths to exclude, relative to the current directory "+
+			"(e.g. '**/mocks/', or '!testdata/' to re-include the testdata directories)").Strings()
+	exemptPatterns = kingpin.Flag(
+		"exempt-pattern",
+		"Pattern of unbreakable tokens (URLs, string literals, etc.) that exempt a line from shortening").
+		RegexpList()
+	filesFrom = kingpin.Flag(
+		"files-from",
+		"Read the paths to format from a file, or from stdin with '-', one per line").
+		PlaceHolder("<path|->").String()
+	followSymlinks = kingpin.Flag(
+		"follow-symlinks",
+		"Follow the symbolic links to directories").Default("false").Bool()
+	generatedFiles = kingpin.Flag(
+		"generated-file",
+		"Glob of the names of the generated files (e.g. 'zz_generated.*.go')").
+		Default("generated_*").Strings()
+	generatedHeaders = kingpin.Flag(
+		"generated-header",
+		"Pattern of a header comment line that identifies the generated files, "+
+			"for the generators that don't follow the Go convention").RegexpList()
+	generatedMode = kingpin.Flag(
+		"generated-mode",
+		"How to detect the generated files from their header comments: "+
+			"lax (any comment mentioning 'do not edit' or 'generated by'), "+
+			"or strict (the Go convention '^// Code generated .* DO NOT EDIT\\.$')").
+		Default(generatedModeLax).Enum(generatedModeLax, generatedModeStrict)
+	gitignore = kingpin.Flag(
+		"gitignore",
+		"Skip the paths matched by the .gitignore files (the .golinesignore files are always read)").
+		Default("false").Bool()
+	graphFormat = kingpin.Flag(
+		"graph-format",
+		"Format of the AST graph written to --dot-file: dot, json, mermaid, or html").
+		Default(shorten.GraphFormatDot).
+		Enum(shorten.GraphFormatDot, shorten.GraphFormatJSON, shorten.GraphFormatMermaid, shorten.GraphFormatHTML)
+	graphPerRound = kingpin.Flag(
+		"graph-per-round",
+		"Write the AST graph of each round to its own file (name.round-N.dot) instead of overwriting --dot-file").
+		Default("false").Bool()
+	graphScope = kingpin.Flag(
+		"graph-scope",
+		"Render only the smallest declaration or statement enclosing a line (e.g. '123'), "+
+			"a function (e.g. 'myFunc' or 'Type.Method'), or a byte range (e.g. '100-250') in the AST graph").
+		Default("").String()
 	ignoreGenerated = kingpin.Flag(
 		"ignore-generated",
 		"Ignore generated go files").Default("true").Bool()
 	ignoredDirs = kingpin.Flag(
 		"ignored-dirs",
-		"Directories to ignore").Default("vendor", "testdata", "node_modules").Strings()
+		"Names of the directories to ignore, at any level").Default("vendor", "testdata", "node_modules").Strings()
+	includes = kingpin.Flag(
+		"include",
+		"Gitignore-like pattern of the files to process, relative to the current directory "+
+			"(e.g. 'pkg/**/*.go'); all the files are processed if empty").Strings()
 	keepAnnotations = kingpin.Flag(
 		"keep-annotations",
 		"Keep shortening annotations in the final output").Default("false").Bool()
 	listFiles = kingpin.Flag(
 		"list-files",
 		"List files that would be reformatted by this tool").Short('l').Default("false").Bool()
+	maxCommentLen = kingpin.Flag(
+		"max-comment-len",
+		"Target maximum comment line length (defaults to the max-len value)").Default("0").Int()
 	maxLen = kingpin.Flag(
 		"max-len",
 		"Target maximum line length").Short('m').Default("100").Int()
+	maxRounds = kingpin.Flag(
+		"max-rounds",
+		"Maximum number of shortening rounds").Default(strconv.Itoa(shorten.DefaultMaxRounds)).Int()
+	moduleBoundaries = kingpin.Flag(
+		"module-boundaries",
+		"How to walk into the nested modules: cross, stop (skip the directories with a go.mod file), "+
+			"or workspace (skip them, except the modules listed in the go.work file)").
+		Default(moduleBoundariesCross).
+		Enum(moduleBoundariesCross, moduleBoundariesStop, moduleBoundariesWorkspace)
+	moveTrailingComments = kingpin.Flag(
+		"move-trailing-comments",
+		"Move long trailing comments on their own line, above the code (directives are kept in place)").
+		Default("false").Bool()
+	nullSeparator = kingpin.Flag(
+		"null",
+		"The paths read with --files-from are separated by NUL characters instead of newlines").
+		Short('0').Default("false").Bool()
+	outputDir = kingpin.Flag(
+		"output-dir",
+		"Write the processed files in another directory, under the same relative paths, "+
+			"instead of stdout").PlaceHolder("<dir>").String()
+	overlayFile = kingpin.Flag(
+		"overlay",
+		"Write the changed files in a temporary directory, and a 'go build -overlay' JSON file "+
+			"mapping the original files to them").PlaceHolder("<file.json>").String()
+	patchFileFlag = kingpin.Flag(
+		"patch-file",
+		"Write the diffs of all the changed files in a single patch file, "+
+			"that can be applied with 'git apply'").PlaceHolder("<path>").String()
 	profile = kingpin.Flag(
 		"profile",
 		"Path to profile output").Default("").String()
+	rebalanceComments = kingpin.Flag(
+		"rebalance-comments",
+		"Rewrap whole comment paragraphs, including short lines (requires shorten-comments)").
+		Default("false").Bool()
 	reformatTags = kingpin.Flag(
 		"reformat-tags",
 		"Reformat struct tags").Default("true").Bool()
+	setExitStatus = kingpin.Flag(
+		"set-exit-status",
+		"Exit with 1 if some files are (or would be) changed, 2 on errors").
+		Default("false").Bool()
 	shortenComments = kingpin.Flag(
 		"shorten-comments",
 		"Shorten single-line comments").Default("false").Bool()
+	statsFormat = kingpin.Flag(
+		"stats",
+		"Print statistics at the end of the run: files, lines shortened, rounds, "+
+			"constructs split, and the slowest files, as text or json").
+		PlaceHolder("text|json").Enum(statsText, statsJSON)
+	stdinFilename = kingpin.Flag(
+		"stdin-filename",
+		"Name of the input read from stdin, for the ignore decisions and the error messages "+
+			"(the output is still written to stdout)").PlaceHolder("path/to/file.go").String()
+	tagAlignment = kingpin.Flag(
+		"tag-alignment",
+		"How to reformat struct tags: none, blocks (align the keys within blocks of fields), or spacing").
+		Default(shorten.TagAlignmentBlocks).
+		Enum(shorten.TagAlignmentNone, shorten.TagAlignmentBlocks, shorten.TagAlignmentSpacing)
+	tagOrder = kingpin.Flag(
+		"tag-order",
+		"Canonical order of the struct tag keys, comma-separated (e.g. json,yaml,db,validate); "+
+			"the other keys are sorted alphabetically after them").Strings()
 	tabLen = kingpin.Flag(
 		"tab-len",
 		"Length of a tab").Short('t').Default("4").Int()
+	tolerant = kingpin.Flag(
+		"tolerant",
+		"Shorten the declarations that parse in the files with syntax errors, keep the broken ones unchanged, "+
+			"and report the syntax errors as warnings").Default("false").Bool()
+	traceFileFlag = kingpin.Flag(
+		"trace",
+		"Write the decisions of the shortener (which node was split, how, and whether the line converged) "+
+			"in a JSON lines file, one event per line").PlaceHolder("<file.jsonl>").String()
+	validateTags = kingpin.Flag(
+		"validate-tags",
+		"Report malformed struct tags, duplicate keys, and unquoted values").Default("false").Bool()
+	verify = kingpin.Flag(
+		"verify",
+		"Check that the shortening keeps the syntax tree and the comments of each file, "+
+			"and report an internal error with a minimized reproducer otherwise").Default("false").Bool()
 	versionFlag = kingpin.Flag(
 		"version",
 		"Print out version and exit").Default("false").Bool()
@@ -79,15 +228,35 @@ var (
 		"write-output",
 		"Write output to source instead of stdout").Short('w').Default("false").Bool()
 
+	// Commands.
+	formatCmd = kingpin.Command(
+		"format",
+		"Shorten the long lines of Go files (default command)").Default()
+	restoreCmd = kingpin.Command(
+		"restore",
+		"Put the backups made with --backup back in place of the files")
+	explainCmd = kingpin.Command(
+		"explain",
+		"Explain how a line is shortened: its syntax tree path, the rules applied in each round, "+
+			"and why the shortening stopped")
+
 	// Args.
-	paths = kingpin.Arg(
+	paths = formatCmd.Arg(
 		"paths",
-		"Paths to format",
+		"Paths to format: files, directories, or package patterns (e.g. ./...)",
 	).Strings()
+	restorePaths = restoreCmd.Arg(
+		"paths",
+		"Paths to restore: files, directories, or package patterns (e.g. ./...)",
+	).Default(".").Strings()
+	explainLocation = explainCmd.Arg(
+		"location",
+		"Line to explain, as file.go:line",
+	).Required().String()
 )
 
 func main() {
-	kingpin.Parse()
+	command := kingpin.Parse()
 
 	if deref(debugFlag) {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
@@ -102,7 +271,21 @@ func main() {
 
 	s := newSequencer(maxWeight, os.Stdout, os.Stderr)
 
-	run(s)
+	switch command {
+	case restoreCmd.FullCommand():
+		NewRunner().restoreBackups(s, deref(restorePaths), deref(backupSuffix))
+
+	case explainCmd.FullCommand():
+		NewRunner().explain(s, deref(explainLocation))
+
+	default:
+		run(s)
+
+		// The summary goes with the modes reporting the files instead of printing their content.
+		if !deref(versionFlag) && (deref(listFiles) || deref(dryRun) || deref(setExitStatus)) {
+			s.AddSummary()
+		}
+	}
 
 	os.Exit(s.GetExitCode())
 }
@@ -136,12 +319,30 @@ func run(s *sequencer) {
 }
 
 type Runner struct {
-	args            []string
-	ignoredDirs     []string
-	ignoreGenerated bool
-	dryRun          bool
-	listFiles       bool
-	writeOutput     bool
+	args             []string
+	filesFrom        string
+	nullSeparator    bool
+	selector         *fileSelector
+	followSymlinks   bool
+	moduleBoundaries string
+	workspaceModules map[string]bool
+	ignoreGenerated  bool
+	generated        *generatedDetector
+	dryRun           bool
+	listFiles        bool
+	writeOutput      bool
+	backupSuffix     string
+
+	stdinFilename string
+	setExitStatus bool
+	outputDir     string
+	overlay       *overlay
+	color         string
+	diff          diff.Options
+	patch         *patchFile
+	stats         *runStats
+	trace         *traceFile
+	tolerant      bool
 
 	shortener *shorten.Shortener
 
@@ -150,22 +351,69 @@ type Runner struct {
 
 func NewRunner() *Runner {
 	config := &shorten.Config{
-		MaxLen:          deref(maxLen),
-		TabLen:          deref(tabLen),
-		KeepAnnotations: deref(keepAnnotations),
-		ShortenComments: deref(shortenComments),
-		ReformatTags:    deref(reformatTags),
-		DotFile:         deref(dotFile),
-		ChainSplitDots:  deref(chainSplitDots),
+		MaxLen:               deref(maxLen),
+		TabLen:               deref(tabLen),
+		MaxRounds:            deref(maxRounds),
+		CheckIdempotency:     deref(checkIdempotency),
+		Verify:               deref(verify),
+		Tolerant:             deref(tolerant),
+		KeepAnnotations:      deref(keepAnnotations),
+		ShortenComments:      deref(shortenComments),
+		CommentMaxLen:        deref(maxCommentLen),
+		RebalanceComments:    deref(rebalanceComments),
+		MoveTrailingComments: deref(moveTrailingComments),
+		ReformatTags:         deref(reformatTags),
+		TagAlignment:         deref(tagAlignment),
+		TagOrder:             splitList(deref(tagOrder)),
+		ValidateTags:         deref(validateTags),
+		DotFile:              deref(dotFile),
+		GraphFormat:          deref(graphFormat),
+		GraphScope:           deref(graphScope),
+		GraphPerRound:        deref(graphPerRound),
+		ChainSplitDots:       deref(chainSplitDots),
+		ExemptPatterns:       deref(exemptPatterns),
+		Trace:                deref(traceFileFlag) != "",
+	}
+
+	selector := newFileSelector(deref(ignoredDirs), deref(includes), deref(excludes), deref(gitignore))
+
+	// The files written in the output directory must not be processed again.
+	if deref(outputDir) != "" {
+		selector.excludeDir(deref(outputDir))
 	}
 
 	return &Runner{
-		args:            deref(paths),
-		ignoredDirs:     deref(ignoredDirs),
-		ignoreGenerated: deref(ignoreGenerated),
-		dryRun:          deref(dryRun),
-		listFiles:       deref(listFiles),
-		writeOutput:     deref(writeOutput),
+		args:             deref(paths),
+		filesFrom:        deref(filesFrom),
+		nullSeparator:    deref(nullSeparator),
+		selector:         selector,
+		followSymlinks:   deref(followSymlinks),
+		moduleBoundaries: deref(moduleBoundaries),
+		ignoreGenerated:  deref(ignoreGenerated),
+		generated: &generatedDetector{
+			mode:           deref(generatedMode),
+			filePatterns:   deref(generatedFiles),
+			headerPatterns: deref(generatedHeaders),
+		},
+		dryRun:       deref(dryRun),
+		listFiles:    deref(listFiles),
+		writeOutput:  deref(writeOutput),
+		backupSuffix: deref(backupSuffix),
+
+		stdinFilename: deref(stdinFilename),
+		setExitStatus: deref(setExitStatus),
+		outputDir:     deref(outputDir),
+		overlay:       newOverlay(deref(overlayFile)),
+		color:         deref(colorMode),
+		diff: diff.Options{
+			Style:   deref(diffStyle),
+			Context: deref(diffContext),
+		},
+		patch: newPatchFile(deref(patchFileFlag), deref(diffContext)),
+		stats: newRunStats(deref(statsFormat)),
+		trace: newTraceFile(deref(traceFileFlag)),
+
+		tolerant: deref(tolerant),
 
 		shortener:      shorten.NewShortener(config, shorten.WithLogger(slog.Default())),
 		extraFormatter: formatter.NewExecutable(deref(baseFormatterCmd)),
@@ -173,67 +421,309 @@ func NewRunner() *Runner {
 }
 
 func (r *Runner) run(s *sequencer) {
-	// Read input from stdin
-	if len(r.args) == 0 {
-		s.Add(0, func(rp *reporter) error {
-			return r.processFile("<standard input>", nil, os.Stdin, rp)
+	r.addInputs(s)
+
+	if r.overlay != nil {
+		// The overlay file maps all the changed files.
+		s.Add(exclusive, func(*reporter) error {
+			return r.overlay.write()
+		})
+	}
+
+	if r.patch != nil {
+		// The patch file contains the diffs of all the changed files.
+		s.Add(exclusive, func(*reporter) error {
+			return r.patch.write()
 		})
+	}
+
+	if r.trace != nil {
+		// The trace file contains the decisions on all the files.
+		s.Add(exclusive, func(*reporter) error {
+			return r.trace.write()
+		})
+	}
+
+	if r.stats != nil {
+		r.stats.addTo(s)
+	}
+}
+
+// addInputs adds the inputs to the sequencer: stdin, the paths provided in arguments,
+// and the paths listed in a file.
+func (r *Runner) addInputs(s *sequencer) {
+	// Read input from stdin
+	if len(r.args) == 0 && r.filesFrom == "" {
+		r.addStdin(s)
 
 		return
 	}
 
+	if r.moduleBoundaries == moduleBoundariesWorkspace {
+		err := r.loadWorkspace()
+		if err != nil {
+			s.AddReport(err)
+		}
+	}
+
 	// Read inputs from paths provided in arguments
 	for _, arg := range r.args {
-		switch info, err := os.Stat(arg); {
-		case err != nil:
+		r.addPath(arg, s)
+	}
+
+	// Read inputs from paths listed in a file
+	if r.filesFrom != "" {
+		err := r.readFilesFrom(func(name string) {
+			r.addPath(name, s)
+		})
+		if err != nil {
 			s.AddReport(err)
+		}
+	}
+}
+
+// addStdin adds the input from stdin to the sequencer.
+// The stdinFilename option names the input for the ignore decisions and the error messages:
+// an ignored input is written unchanged.
+func (r *Runner) addStdin(s *sequencer) {
+	name := cmp.Or(r.stdinFilename, "<standard input>")
+
+	var ignored bool
+
+	if r.stdinFilename != "" {
+		err := r.selector.loadParentIgnoreFiles(name)
+		if err != nil {
+			s.AddReport(err)
+
+			return
+		}
+
+		ignored = r.selector.isExcludedArg(name, false) || r.isIgnoredFile(name)
+	}
+
+	s.AddFile(name, 0, func(rp *reporter) error {
+		if !ignored {
+			return r.processFile(name, nil, os.Stdin, rp)
+		}
+
+		slog.Debug("input ignored, copying it", slog.String("path", name))
+
+		content, err := readFile(name, nil, os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		return r.handleOutput(name, content, content, nil, rp)
+	})
+}
+
+// addPath adds the files of a path (a file, a directory, or a package pattern) to the sequencer.
+func (r *Runner) addPath(arg string, s *sequencer) {
+	if isPattern(arg) {
+		r.walkPattern(arg, s)
+
+		return
+	}
+
+	switch info, err := os.Stat(arg); {
+	case err != nil:
+		s.AddReport(err)
+
+	case !info.IsDir():
+		err = r.selector.loadParentIgnoreFiles(arg)
+		if err != nil {
+			s.AddReport(err)
+
+			return
+		}
+
+		if r.selector.isExcludedArg(arg, false) || r.isIgnoredFile(arg) {
+			return
+		}
+
+		s.AddFile(arg, fileWeight(arg, info), func(rp *reporter) error {
+			return r.processFile(arg, info, nil, rp)
+		})
+
+	default:
+		err = r.selector.loadParentIgnoreFiles(arg)
+		if err != nil {
+			s.AddReport(err)
+
+			return
+		}
+
+		if r.selector.isExcludedArg(arg, true) {
+			return
+		}
+
+		// Path is a directory, walk it
+		err = r.walk(arg, s, map[string]bool{}, nil)
+		if err != nil {
+			s.AddReport(err)
+		}
+	}
+}
+
+// readFilesFrom reads the paths listed in a file, or in stdin with `-`,
+// one per line, or separated by NUL characters with the nullSeparator option.
+func (r *Runner) readFilesFrom(fn func(name string)) error {
+	in := io.Reader(os.Stdin)
+
+	if r.filesFrom != "-" {
+		file, err := os.Open(r.filesFrom)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = file.Close() }()
+
+		in = file
+	}
+
+	separator := byte('\n')
+	if r.nullSeparator {
+		separator = 0
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, separator); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+	})
+
+	for scanner.Scan() {
+		name := scanner.Text()
+		if !r.nullSeparator {
+			name = strings.TrimSuffix(name, "\r")
+		}
+
+		if name == "" {
+			continue
+		}
+
+		fn(name)
+	}
+
+	return scanner.Err()
+}
+
+// walkPattern adds the files of the directories matching a package pattern (e.g., `./...`) to the sequencer.
+func (r *Runner) walkPattern(pattern string, s *sequencer) {
+	root := patternRoot(pattern)
+
+	info, err := os.Stat(root)
+	if err != nil {
+		s.AddReport(err)
+
+		return
+	}
+
+	if !info.IsDir() {
+		s.AddReport(fmt.Errorf("%s: not a directory", root))
+
+		return
+	}
 
-		case !info.IsDir():
-			if r.isIgnoredFile(arg) {
-				return
+	err = r.selector.loadParentIgnoreFiles(root)
+	if err != nil {
+		s.AddReport(err)
+
+		return
+	}
+
+	if r.selector.isExcludedArg(root, true) {
+		return
+	}
+
+	err = r.walk(root, s, map[string]bool{}, matchPattern(pattern))
+	if err != nil {
+		s.AddReport(err)
+	}
+}
+
+// walk adds the files of a directory to the sequencer.
+// The symbolic links to directories are followed only with the followSymlinks option,
+// the visited directories are tracked to avoid the cycles.
+// If match is not nil, only the files of the matching directories are added (package patterns).
+func (r *Runner) walk(root string, s *sequencer, visited map[string]bool, match func(dir string) bool) error {
+	if realPath, err := filepath.EvalSymlinks(root); err == nil {
+		if visited[realPath] {
+			return nil
+		}
+
+		visited[realPath] = true
+	}
+
+	start := root
+
+	if f, err := os.Lstat(root); err == nil && f.Mode()&fs.ModeSymlink != 0 {
+		// The trailing separator makes WalkDir walk the target of the symbolic link.
+		start = root + string(filepath.Separator)
+	}
+
+	return filepath.WalkDir(start, func(path string, f fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if f.IsDir() {
+			if path != start && r.skipDir(path, f.Name(), match != nil) {
+				return filepath.SkipDir
 			}
 
-			s.Add(fileWeight(arg, info), func(rp *reporter) error {
-				return r.processFile(arg, info, nil, rp)
-			})
+			return r.selector.loadIgnoreFiles(path)
+		}
 
-		default:
-			// Path is a directory, walk it
-			err = filepath.WalkDir(arg, func(path string, f fs.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
+		var info fs.FileInfo
 
-				if r.skipDir(path, f) {
-					return filepath.SkipDir
-				}
+		if f.Type()&fs.ModeSymlink != 0 && r.followSymlinks {
+			info, err = os.Stat(path)
+			if err != nil {
+				s.AddReport(err)
 
-				if f.IsDir() {
-					return nil
-				}
+				return nil
+			}
 
-				if r.isIgnoredFile(path) {
+			if info.IsDir() {
+				if r.skipDir(path, f.Name(), match != nil) {
 					return nil
 				}
 
-				info, err := f.Info()
-				if err != nil {
-					s.AddReport(err)
+				return r.walk(path, s, visited, match)
+			}
+		}
 
-					return nil
-				}
+		if match != nil && !match(filepath.Dir(path)) {
+			return nil
+		}
 
-				s.Add(fileWeight(path, info), func(rp *reporter) error {
-					return r.processFile(path, info, nil, rp)
-				})
+		if r.selector.isExcluded(path, false) || r.isIgnoredFile(path) {
+			return nil
+		}
 
-				return nil
-			})
+		if info == nil {
+			info, err = f.Info()
 			if err != nil {
 				s.AddReport(err)
+
+				return nil
 			}
 		}
-	}
+
+		s.AddFile(path, fileWeight(path, info), func(rp *reporter) error {
+			return r.processFile(path, info, nil, rp)
+		})
+
+		return nil
+	})
 }
 
 func (r *Runner) processFile(path string, info fs.FileInfo, in io.Reader, rp *reporter) error {
@@ -244,29 +734,66 @@ func (r *Runner) processFile(path string, info fs.FileInfo, in io.Reader, rp *re
 		return err
 	}
 
-	if r.ignoreGenerated && isGenerated(content) {
+	r.stats.addScanned()
+
+	if r.ignoreGenerated && r.generated.isGenerated(content) {
+		r.stats.addGenerated()
+
+		if in != nil {
+			// The input from stdin is expected in the output.
+			return r.handleOutput(path, content, content, info, rp)
+		}
+
 		return nil
 	}
 
+	start := time.Now()
+
 	// Do initial, non-line-length-aware formatting
 	result, err := r.extraFormatter.Format(context.Background(), content)
 	if err != nil {
-		return err
+		if !r.tolerant {
+			return shorten.NewError(shorten.StageBaseFormatter, path, err)
+		}
+
+		// The shortener reports the syntax errors, the file is shortened without the base formatter.
+		rp.Warnf("%s (the base formatter is skipped)\n", shorten.NewError(shorten.StageBaseFormatter, path, err))
+
+		result = content
 	}
 
-	result, err = r.shortener.Process(result)
+	// The positions and the graph scope are the ones of the file, before the base formatter.
+	res, err := r.shortener.ProcessFileFrom(path, content, result)
 	if err != nil {
 		return err
 	}
 
-	if !r.extraFormatter.IsGofmtCompliant() {
-		// Do the final round of non-line-length-aware formatting after we've fixed up the comments
-		result, err = r.extraFormatter.Format(context.Background(), result)
+	for _, warning := range res.Warnings {
+		rp.Warnf("%s\n", warning)
+	}
+
+	if r.trace != nil {
+		err = r.trace.add(path, res.Trace)
 		if err != nil {
 			return err
 		}
 	}
 
+	result = res.Content
+
+	if !r.extraFormatter.IsGofmtCompliant() {
+		// Do the final round of non-line-length-aware formatting after we've fixed up the comments
+		formatted, err := r.extraFormatter.Format(context.Background(), result)
+		switch {
+		case err == nil:
+			result = formatted
+		case !r.tolerant:
+			return shorten.NewError(shorten.StageBaseFormatter, path, err)
+		}
+	}
+
+	r.stats.addShortened(path, res.Stats, time.Since(start))
+
 	return r.handleOutput(path, content, result, info, rp)
 }
 
@@ -279,7 +806,20 @@ func (r *Runner) handleOutput(
 	info fs.FileInfo,
 	rp *reporter,
 ) error {
-	if !r.listFiles && !r.writeOutput && !r.dryRun {
+	// The files are written in the output directory or the overlay instead of stdout,
+	// there is no file info for the input from stdin.
+	redirected := info != nil && (r.outputDir != "" || r.overlay != nil)
+
+	if redirected && r.outputDir != "" {
+		err := r.writeMirror(filename, res, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	rp.markChecked()
+
+	if !r.listFiles && !r.writeOutput && !r.dryRun && !redirected {
 		_, _ = rp.Write(res)
 	}
 
@@ -289,22 +829,36 @@ func (r *Runner) handleOutput(
 		return nil
 	}
 
+	rp.markChanged(r.setExitStatus)
+
+	if redirected && r.overlay != nil {
+		err := r.overlay.add(r, filename, res, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.patch != nil {
+		r.patch.add(filename, src, res)
+	}
+
 	if r.listFiles {
 		_, _ = fmt.Fprintln(rp, filename)
 	}
 
 	if r.writeOutput {
-		if filename == "" {
+		// There is no file info for the input from stdin.
+		if filename == "" || info == nil {
 			return errors.New("no path to write out to")
 		}
 
 		slog.Debug("content changed, writing output", slog.String("path", filename))
 
-		return writeFile(filename, src, res, info.Mode().Perm(), info.Size())
+		return writeFile(filename, src, res, r.backupSuffix)
 	}
 
 	if r.dryRun {
-		_, _ = rp.Write(diff.Pretty(filename, src, res))
+		_, _ = rp.Write(diff.Pretty(filename, src, res, r.diffOptions(rp)))
 
 		return nil
 	}
@@ -312,6 +866,21 @@ func (r *Runner) handleOutput(
 	return nil
 }
 
+// splitList splits the comma-separated values of a repeatable flag.
+func splitList(values []string) []string {
+	var result []string
+
+	for _, value := range values {
+		for elt := range strings.SplitSeq(value, ",") {
+			if elt = strings.TrimSpace(elt); elt != "" {
+				result = append(result, elt)
+			}
+		}
+	}
+
+	return result
+}
+
 func deref[T any](v *T) T { //nolint:ireturn
 	if v == nil {
 		var zero T
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/golangci/golines/internal/formatter"
+	"github.com/golangci/golines/shorten"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -159,3 +161,436 @@ func writeTestFiles(
 
 	return filePaths
 }
+
+func Test_runner_run_selection(t *testing.T) {
+	longFile := testFiles["test1.go"]
+
+	testCases := []struct {
+		desc           string
+		ignoredDirs    []string
+		includes       []string
+		excludes       []string
+		gitignore      bool
+		followSymlinks bool
+		expected       []string
+	}{
+		{
+			desc:        "defaults",
+			ignoredDirs: []string{"vendor", "testdata", "node_modules"},
+			expected:    []string{"a.go", "pkg/f.go", "pkg/ignored.go"},
+		},
+		{
+			desc:        "re-include testdata",
+			ignoredDirs: []string{"vendor", "testdata", "node_modules"},
+			excludes:    []string{"!testdata/"},
+			expected:    []string{"a.go", "pkg/f.go", "pkg/ignored.go", "testdata/b.go"},
+		},
+		{
+			desc:        "exclude with double star",
+			ignoredDirs: []string{"vendor", "testdata", "node_modules"},
+			excludes:    []string{"pkg/**"},
+			expected:    []string{"a.go"},
+		},
+		{
+			desc:        "include",
+			ignoredDirs: []string{"vendor", "testdata", "node_modules"},
+			includes:    []string{"pkg/**/*.go"},
+			expected:    []string{"pkg/f.go", "pkg/ignored.go"},
+		},
+		{
+			desc:        "gitignore",
+			ignoredDirs: []string{"vendor", "testdata", "node_modules"},
+			gitignore:   true,
+			expected:    []string{"a.go", "pkg/f.go"},
+		},
+		{
+			desc:           "follow symlinks",
+			ignoredDirs:    []string{"vendor", "testdata", "node_modules"},
+			followSymlinks: true,
+			expected:       []string{"a.go", "linked/h.go", "pkg/f.go", "pkg/ignored.go"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			root := filepath.Join(tmpDir, "root")
+
+			for _, name := range []string{
+				"a.go", "testdata/b.go", "vendor/c.go", ".hidden/d.go", "gen/e.go",
+				"pkg/f.go", "pkg/ignored.go",
+			} {
+				path := filepath.Join(root, filepath.FromSlash(name))
+
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(longFile), 0o644))
+			}
+
+			require.NoError(t, os.WriteFile(filepath.Join(root, ".golinesignore"), []byte("/gen/\n"), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(root, "pkg", ".gitignore"), []byte("ignored.go\n"), 0o644))
+
+			external := filepath.Join(tmpDir, "external")
+
+			require.NoError(t, os.MkdirAll(external, 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(external, "h.go"), []byte(longFile), 0o644))
+
+			err := os.Symlink(external, filepath.Join(root, "linked"))
+			if err != nil {
+				t.Skipf("symbolic links not supported: %v", err)
+			}
+
+			t.Chdir(root)
+
+			runner := NewRunner()
+			runner.args = []string{"."}
+			runner.listFiles = true
+			runner.followSymlinks = test.followSymlinks
+			runner.selector = newFileSelector(test.ignoredDirs, test.includes, test.excludes, test.gitignore)
+
+			var buf bytes.Buffer
+
+			s := newSequencer(1, &buf, os.Stderr)
+
+			runner.run(s)
+			require.Equal(t, 0, s.GetExitCode())
+
+			actual := strings.Fields(filepath.ToSlash(buf.String()))
+
+			slices.Sort(actual)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func Test_runner_run_explicitFiles(t *testing.T) {
+	root := t.TempDir()
+
+	names := []string{"testdata/a.go", "vendor/b.go", ".hidden/c.go", "gen/d.go", "pkg/e.go"}
+
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(testFiles["test1.go"]), 0o644))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".golinesignore"), []byte("/gen/\n"), 0o644))
+
+	t.Chdir(root)
+
+	runner := NewRunner()
+	runner.args = names
+	runner.listFiles = true
+	runner.selector = newFileSelector([]string{"vendor", "testdata"}, nil, []string{"pkg/"}, false)
+
+	var buf bytes.Buffer
+
+	s := newSequencer(1, &buf, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	actual := strings.Fields(filepath.ToSlash(buf.String()))
+
+	slices.Sort(actual)
+
+	// The default ignored directories only apply to the walked directories,
+	// the user's patterns apply to the explicit files as well.
+	assert.Equal(t, []string{".hidden/c.go", "testdata/a.go", "vendor/b.go"}, actual)
+}
+
+func Test_runner_run_filesFrom(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		separator     string
+		nullSeparator bool
+	}{
+		{
+			desc:      "newlines",
+			separator: "\n",
+		},
+		{
+			desc:      "CRLF",
+			separator: "\r\n",
+		},
+		{
+			desc:          "NUL characters",
+			separator:     "\x00",
+			nullSeparator: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			updatedTestFiles := maps.Clone(testFiles)
+			updatedTestFiles["notes.txt"] = "not a go file\n"
+
+			paths := writeTestFiles(t, updatedTestFiles, tmpDir)
+
+			// The ignored files don't stop the processing of the other files.
+			slices.SortFunc(paths, func(a, b string) int {
+				return strings.Compare(filepath.Ext(b), filepath.Ext(a))
+			})
+
+			listFile := filepath.Join(t.TempDir(), "files")
+
+			err := os.WriteFile(listFile, []byte(strings.Join(paths, test.separator)+test.separator), 0o644)
+			require.NoError(t, err)
+
+			runner := NewRunner()
+			runner.listFiles = true
+			runner.filesFrom = listFile
+			runner.nullSeparator = test.nullSeparator
+
+			var buf bytes.Buffer
+
+			s := newSequencer(1, &buf, os.Stderr)
+
+			runner.run(s)
+			require.Equal(t, 0, s.GetExitCode())
+
+			expectedPaths := []string{
+				filepath.Join(tmpDir, "test1.go"),
+				filepath.Join(tmpDir, "test2.go"),
+			}
+
+			actualPaths := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+			slices.Sort(actualPaths)
+
+			assert.Equal(t, expectedPaths, actualPaths)
+		})
+	}
+}
+
+func Test_runner_run_stdinFilename(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		stdinFilename string
+		excludes      []string
+		content       string
+		tolerant      bool
+		expectedOut   string
+		expectedErr   string
+		exitCode      int
+	}{
+		{
+			desc:          "shortened",
+			stdinFilename: "pkg/test1.go",
+			content:       testFiles["test1.go"],
+			expectedOut:   "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\n",
+		},
+		{
+			desc:          "default ignored directory",
+			stdinFilename: "vendor/test1.go",
+			content:       testFiles["test1.go"],
+			expectedOut:   "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Printf(\n",
+		},
+		{
+			desc:          "excluded",
+			stdinFilename: "pkg/test1.go",
+			excludes:      []string{"pkg/"},
+			content:       testFiles["test1.go"],
+			expectedOut:   testFiles["test1.go"],
+		},
+		{
+			desc:          "generated file name",
+			stdinFilename: "pkg/generated_test1.go",
+			content:       testFiles["test1.go"],
+			expectedOut:   testFiles["test1.go"],
+		},
+		{
+			desc:          "syntax error",
+			stdinFilename: "pkg/broken.go",
+			content:       "package main\n\nfunc main( {\n}\n",
+			expectedErr:   "error formatting source: pkg/broken.go:3:12: expected ')', found '{' (and 1 more errors)\n",
+			exitCode:      2,
+		},
+		{
+			desc:        "syntax error without name",
+			content:     "package main\n\nfunc main( {\n}\n",
+			expectedErr: "error formatting source: <standard input>:3:12: expected ')', found '{' (and 1 more errors)\n",
+			exitCode:    2,
+		},
+		{
+			desc:          "syntax error tolerant",
+			stdinFilename: "pkg/broken.go",
+			content:       "package main\n\nfunc main( {\n\tprintln()\n}\n\nfunc f() { println() }\n",
+			tolerant:      true,
+			expectedOut:   "package main\n\nfunc main( {\n\tprintln()\n}\n\nfunc f() { println() }\n",
+			expectedErr: "pkg/broken.go:3:12: syntax error: expected ')', found '{' (the declaration is kept unchanged)\n" +
+				"pkg/broken.go:5:1: syntax error: expected declaration, found '}' (the declaration is kept unchanged)\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			stdin := filepath.Join(t.TempDir(), "stdin")
+
+			require.NoError(t, os.WriteFile(stdin, []byte(test.content), 0o644))
+
+			f, err := os.Open(stdin)
+			require.NoError(t, err)
+
+			t.Cleanup(func() { _ = f.Close() })
+
+			origStdin := os.Stdin
+			os.Stdin = f
+
+			t.Cleanup(func() { os.Stdin = origStdin })
+
+			runner := NewRunner()
+			runner.stdinFilename = test.stdinFilename
+			runner.selector = newFileSelector([]string{"vendor"}, nil, test.excludes, false)
+			runner.ignoreGenerated = true
+			runner.generated = &generatedDetector{filePatterns: []string{"generated_*"}}
+
+			if test.tolerant {
+				config := shorten.NewDefaultConfig()
+				config.Tolerant = true
+
+				runner.tolerant = true
+				runner.shortener = shorten.NewShortener(config)
+				runner.extraFormatter = formatter.NewExecutable("gofmt")
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			s := newSequencer(1, &stdout, &stderr)
+
+			runner.run(s)
+			require.Equal(t, test.exitCode, s.GetExitCode(), stderr.String())
+
+			assert.True(t, strings.HasPrefix(stdout.String(), test.expectedOut), stdout.String())
+			assert.Equal(t, test.expectedErr, stderr.String())
+		})
+	}
+}
+
+func Test_runner_run_setExitStatus(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		files           map[string]string
+		missing         bool
+		setExitStatus   bool
+		expectedCode    int
+		expectedSummary string
+	}{
+		{
+			desc:            "clean",
+			files:           map[string]string{"test3.go": "package main\n"},
+			setExitStatus:   true,
+			expectedCode:    0,
+			expectedSummary: "golines: 1 files checked, 0 changed, 0 errored\n",
+		},
+		{
+			desc:            "changed",
+			files:           testFiles,
+			setExitStatus:   true,
+			expectedCode:    1,
+			expectedSummary: "golines: 2 files checked, 2 changed, 0 errored\n",
+		},
+		{
+			desc:            "changed without exit status",
+			files:           testFiles,
+			expectedCode:    0,
+			expectedSummary: "golines: 2 files checked, 2 changed, 0 errored\n",
+		},
+		{
+			desc:            "errored",
+			files:           testFiles,
+			missing:         true,
+			setExitStatus:   true,
+			expectedCode:    2,
+			expectedSummary: "golines: 2 files checked, 2 changed, 1 errored\n",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			tmpDir := t.TempDir()
+
+			runner := NewRunner()
+			runner.listFiles = true
+			runner.setExitStatus = test.setExitStatus
+			runner.args = writeTestFiles(t, test.files, tmpDir)
+
+			if test.missing {
+				runner.args = append(runner.args, filepath.Join(tmpDir, "missing.go"))
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			s := newSequencer(1, &stdout, &stderr)
+
+			runner.run(s)
+			s.AddSummary()
+
+			require.Equal(t, test.expectedCode, s.GetExitCode())
+
+			lines := strings.SplitAfter(stderr.String(), "\n")
+			assert.Equal(t, test.expectedSummary, lines[len(lines)-2])
+		})
+	}
+}
+
+func Test_runner_run_panic(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runner := NewRunner()
+	runner.writeOutput = true
+	runner.args = writeTestFiles(t, testFiles, tmpDir)
+
+	// A nil shortener panics on each file.
+	runner.shortener = nil
+
+	var stdout, stderr bytes.Buffer
+
+	s := newSequencer(1, &stdout, &stderr)
+
+	runner.run(s)
+	s.AddSummary()
+
+	require.Equal(t, 2, s.GetExitCode())
+
+	for _, path := range runner.args {
+		assert.Contains(t, stderr.String(), path+": internal error: panic: ")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, testFiles[filepath.Base(path)], string(content))
+	}
+
+	assert.Contains(t, stderr.String(), "golines: 0 files checked, 0 changed, 2 errored\n")
+}
+
+func Test_sequencer_panic(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	s := newSequencer(1, &stdout, &stderr)
+
+	s.Add(0, func(*reporter) error {
+		panic("unexpected")
+	})
+
+	s.Add(0, func(r *reporter) error {
+		_, err := r.Write([]byte("next task\n"))
+
+		return err
+	})
+
+	s.AddFile("main.go", 0, func(*reporter) error {
+		panic("unexpected in file")
+	})
+
+	require.Equal(t, 2, s.GetExitCode())
+
+	assert.Equal(t, "next task\n", stdout.String())
+	assert.True(t, strings.HasPrefix(stderr.String(), "internal error: panic: unexpected\n\ngoroutine "), stderr.String())
+	assert.Contains(t, stderr.String(), "\nmain.go: internal error: panic: unexpected in file\n\ngoroutine ")
+}
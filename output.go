@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// mirrorPath returns the path of a file in a mirror tree:
+// the path relative to the current directory, or the absolute path (without the volume name),
+// under the mirror directory.
+func (r *Runner) mirrorPath(dir, filename string) string {
+	rel, _ := r.selector.relPath(filename)
+
+	rel = strings.TrimPrefix(rel, filepath.ToSlash(filepath.VolumeName(rel)))
+
+	return filepath.Join(dir, filepath.FromSlash(rel))
+}
+
+// writeMirror writes the content of a processed file in the output directory.
+func (r *Runner) writeMirror(filename string, content []byte, perm fs.FileMode) error {
+	return writeNewFile(r.mirrorPath(r.outputDir, filename), content, perm)
+}
+
+// writeNewFile writes a file, and creates its parent directories if needed.
+func writeNewFile(filename string, content []byte, perm fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0o755)
+	if err != nil {
+		return err
+	}
+
+	fdSem <- true
+	defer func() { <-fdSem }()
+
+	return os.WriteFile(filename, content, perm)
+}
+
+// overlay writes the changed files in a temporary directory,
+// and a JSON file mapping the original files to these copies, for `go build -overlay`.
+//
+// https://pkg.go.dev/cmd/go#hdr-Compile_packages_and_dependencies
+type overlay struct {
+	file string
+
+	mu      sync.Mutex
+	dir     string
+	replace map[string]string
+}
+
+// overlayJSON is the format of the overlay file expected by the go command.
+type overlayJSON struct {
+	Replace map[string]string
+}
+
+func newOverlay(file string) *overlay {
+	if file == "" {
+		return nil
+	}
+
+	return &overlay{
+		file:    file,
+		replace: map[string]string{},
+	}
+}
+
+// add writes a copy of a changed file in the temporary directory (created on the first call).
+func (o *overlay) add(r *Runner, filename string, content []byte, perm fs.FileMode) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+
+	if o.dir == "" {
+		o.dir, err = os.MkdirTemp("", "golines-overlay-")
+		if err != nil {
+			o.mu.Unlock()
+
+			return err
+		}
+	}
+
+	target := r.mirrorPath(o.dir, filename)
+	o.replace[abs] = target
+
+	o.mu.Unlock()
+
+	return writeNewFile(target, content, perm)
+}
+
+// write writes the overlay file, once all the files are processed.
+func (o *overlay) write() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	data, err := json.MarshalIndent(overlayJSON{Replace: o.replace}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(o.file, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runner_run_outputDir(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"test1.go": testFiles["test1.go"],
+		"test3.go": "package main\n",
+	}, root)
+
+	t.Chdir(root)
+
+	runner := NewRunner()
+	runner.args = []string{"."}
+	runner.outputDir = "out"
+	runner.selector = newFileSelector(nil, nil, nil, false)
+	runner.selector.excludeDir("out")
+
+	var stdout bytes.Buffer
+
+	s := newSequencer(1, &stdout, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	assert.Empty(t, stdout.String())
+
+	// The sources are unchanged.
+	content, err := os.ReadFile("test1.go")
+	require.NoError(t, err)
+	assert.Equal(t, testFiles["test1.go"], string(content))
+
+	// All the processed files are in the output directory.
+	content, err = os.ReadFile(filepath.Join("out", "test1.go"))
+	require.NoError(t, err)
+	assert.NotEqual(t, testFiles["test1.go"], string(content))
+
+	content, err = os.ReadFile(filepath.Join("out", "test3.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(content))
+
+	// The output directory is not processed on the next run.
+	s = newSequencer(1, &stdout, os.Stderr)
+
+	runner.listFiles = true
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	assert.Equal(t, "test1.go\n", stdout.String())
+}
+
+func Test_runner_run_overlay(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"go.mod":   "module example.com/overlay\n\ngo 1.24\n",
+		"test1.go": testFiles["test1.go"],
+		"test3.go": "package main\n",
+	}, root)
+
+	t.Chdir(root)
+
+	overlayFile := filepath.Join(t.TempDir(), "overlay.json")
+
+	runner := NewRunner()
+	runner.args = []string{"."}
+	runner.overlay = newOverlay(overlayFile)
+	runner.selector = newFileSelector(nil, nil, nil, false)
+
+	s := newSequencer(1, os.Stdout, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	data, err := os.ReadFile(overlayFile)
+	require.NoError(t, err)
+
+	var result overlayJSON
+
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	// Only the changed files are in the overlay.
+	require.Len(t, result.Replace, 1)
+
+	target, ok := result.Replace[filepath.Join(root, "test1.go")]
+	require.True(t, ok)
+
+	t.Cleanup(func() { _ = os.RemoveAll(filepath.Dir(target)) })
+
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.NotEqual(t, testFiles["test1.go"], string(content))
+
+	// The sources are unchanged.
+	content, err = os.ReadFile("test1.go")
+	require.NoError(t, err)
+	assert.Equal(t, testFiles["test1.go"], string(content))
+
+	// The overlay can be used by the go command.
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	cmd := exec.Command(goBin, "vet", "-overlay", overlayFile, ".")
+	cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
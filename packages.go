@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Modes of handling of the nested modules while walking directories.
+const (
+	// moduleBoundariesCross walks into the nested modules.
+	moduleBoundariesCross = "cross"
+
+	// moduleBoundariesStop skips the nested modules (directories with a go.mod file).
+	moduleBoundariesStop = "stop"
+
+	// moduleBoundariesWorkspace skips the nested modules, except the ones listed in the go.work file.
+	moduleBoundariesWorkspace = "workspace"
+)
+
+// isPattern checks whether an argument is a package pattern like `./...` or `dir/...`.
+func isPattern(arg string) bool {
+	return strings.Contains(arg, "...")
+}
+
+// patternRoot returns the directory to walk for a package pattern,
+// i.e., the directory before the first wildcard.
+func patternRoot(pattern string) string {
+	pattern = filepath.ToSlash(pattern)
+
+	dir, _ := path.Split(pattern[:strings.Index(pattern, "...")])
+	if dir == "" {
+		return "."
+	}
+
+	return filepath.FromSlash(path.Clean(dir))
+}
+
+// matchPattern returns a function matching the directories against a package pattern,
+// the way the go command does: `...` matches any string, including slashes,
+// and a trailing `/...` also matches the directory itself (`dir/...` matches `dir`).
+//
+// https://pkg.go.dev/cmd/go#hdr-Package_lists_and_patterns
+func matchPattern(pattern string) func(dir string) bool {
+	re := regexp.QuoteMeta(path.Clean(filepath.ToSlash(pattern)))
+	re = strings.ReplaceAll(re, `\.\.\.`, `.*`)
+
+	if before, ok := strings.CutSuffix(re, `/.*`); ok {
+		re = before + `(/.*)?`
+	}
+
+	reg := regexp.MustCompile(`^` + re + `$`)
+
+	return func(dir string) bool {
+		return reg.MatchString(path.Clean(filepath.ToSlash(dir)))
+	}
+}
+
+// skipPatternDir checks whether a directory is ignored by the package patterns:
+// like the go command, the directories beginning with `.` or `_`, and the testdata directories are skipped.
+func skipPatternDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata"
+}
+
+// isModuleBoundary checks whether a walked directory is a nested module to skip.
+func (r *Runner) isModuleBoundary(dir string) bool {
+	if r.moduleBoundaries == "" || r.moduleBoundaries == moduleBoundariesCross {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return false
+	}
+
+	if r.moduleBoundaries != moduleBoundariesWorkspace {
+		return true
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return true
+	}
+
+	return !r.workspaceModules[abs]
+}
+
+// loadWorkspace reads the directories of the modules listed in the go.work file (if any).
+func (r *Runner) loadWorkspace() error {
+	workFile, err := findGoWork()
+	if err != nil || workFile == "" {
+		return err
+	}
+
+	data, err := os.ReadFile(workFile)
+	if err != nil {
+		return err
+	}
+
+	work, err := modfile.ParseWork(workFile, data, nil)
+	if err != nil {
+		return err
+	}
+
+	r.workspaceModules = map[string]bool{}
+
+	for _, use := range work.Use {
+		dir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(workFile), dir)
+		}
+
+		r.workspaceModules[filepath.Clean(dir)] = true
+	}
+
+	return nil
+}
+
+// findGoWork returns the path of the go.work file, like the go command:
+// from the GOWORK environment variable, or from the current directory and its parents.
+func findGoWork() (string, error) {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return "", nil
+
+	case "":
+
+	default:
+		return filepath.Abs(gowork)
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		workFile := filepath.Join(dir, "go.work")
+
+		_, err := os.Stat(workFile)
+		if err == nil {
+			return workFile, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_patternRoot(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "...", expected: "."},
+		{pattern: "./...", expected: "."},
+		{pattern: "pkg/...", expected: "pkg"},
+		{pattern: "./pkg/sub/...", expected: filepath.FromSlash("pkg/sub")},
+		{pattern: "pkg/.../mocks", expected: "pkg"},
+		{pattern: "pkg/foo.../bar", expected: "pkg"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.pattern, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, patternRoot(test.pattern))
+		})
+	}
+}
+
+func Test_matchPattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		dir     string
+		assert  assert.BoolAssertionFunc
+	}{
+		{pattern: "./...", dir: ".", assert: assert.True},
+		{pattern: "./...", dir: "pkg/sub", assert: assert.True},
+		{pattern: "pkg/...", dir: "pkg", assert: assert.True},
+		{pattern: "./pkg/...", dir: "pkg/sub", assert: assert.True},
+		{pattern: "pkg/...", dir: "pkgs", assert: assert.False},
+		{pattern: "pkg/...", dir: ".", assert: assert.False},
+		{pattern: "pkg/.../mocks", dir: "pkg/a/b/mocks", assert: assert.True},
+		{pattern: "pkg/.../mocks", dir: "pkg/a/b", assert: assert.False},
+		{pattern: "pkg/foo...", dir: "pkg/foobar", assert: assert.True},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.pattern+" "+test.dir, func(t *testing.T) {
+			t.Parallel()
+
+			test.assert(t, matchPattern(test.pattern)(filepath.FromSlash(test.dir)))
+		})
+	}
+}
+
+func Test_runner_run_patterns(t *testing.T) {
+	longFile := testFiles["test1.go"]
+
+	testCases := []struct {
+		desc             string
+		args             []string
+		moduleBoundaries string
+		expected         []string
+	}{
+		{
+			desc:     "all packages",
+			args:     []string{"./..."},
+			expected: []string{"a.go", "nested/n.go", "pkg/f.go", "pkg/sub/g.go", "work/w.go"},
+		},
+		{
+			desc:     "sub-directory",
+			args:     []string{"./pkg/..."},
+			expected: []string{"pkg/f.go", "pkg/sub/g.go"},
+		},
+		{
+			desc:     "wildcard in the middle",
+			args:     []string{"./.../sub"},
+			expected: []string{"pkg/sub/g.go"},
+		},
+		{
+			desc:             "stop at nested modules",
+			args:             []string{"./..."},
+			moduleBoundaries: moduleBoundariesStop,
+			expected:         []string{"a.go", "pkg/f.go", "pkg/sub/g.go"},
+		},
+		{
+			desc:             "workspace modules",
+			args:             []string{"./..."},
+			moduleBoundaries: moduleBoundariesWorkspace,
+			expected:         []string{"a.go", "pkg/f.go", "pkg/sub/g.go", "work/w.go"},
+		},
+		{
+			desc:             "stop at nested modules in a directory",
+			args:             []string{"."},
+			moduleBoundaries: moduleBoundariesStop,
+			expected:         []string{"_skipped/s.go", "a.go", "pkg/f.go", "pkg/sub/g.go"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Setenv("GOWORK", "")
+
+			root := t.TempDir()
+
+			for name, content := range map[string]string{
+				"go.mod":        "module example.com/root\n",
+				"go.work":       "go 1.24\n\nuse (\n\t.\n\t./work\n)\n",
+				"a.go":          longFile,
+				"pkg/f.go":      longFile,
+				"pkg/sub/g.go":  longFile,
+				"_skipped/s.go": longFile,
+				"nested/go.mod": "module example.com/nested\n",
+				"nested/n.go":   longFile,
+				"work/go.mod":   "module example.com/work\n",
+				"work/w.go":     longFile,
+			} {
+				path := filepath.Join(root, filepath.FromSlash(name))
+
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			}
+
+			t.Chdir(root)
+
+			runner := NewRunner()
+			runner.args = test.args
+			runner.listFiles = true
+			runner.moduleBoundaries = test.moduleBoundaries
+			runner.selector = newFileSelector(nil, nil, nil, false)
+
+			var buf bytes.Buffer
+
+			s := newSequencer(1, &buf, os.Stderr)
+
+			runner.run(s)
+			require.Equal(t, 0, s.GetExitCode())
+
+			actual := strings.Fields(filepath.ToSlash(buf.String()))
+
+			slices.Sort(actual)
+
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
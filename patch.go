@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"sync"
+
+	"github.com/golangci/golines/internal/diff"
+)
+
+// Modes of coloring of the diffs.
+const (
+	// colorAuto colors the diffs when the output is a terminal, unless the NO_COLOR environment variable is set.
+	//
+	// https://no-color.org/
+	colorAuto = "auto"
+
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// diffOptions returns the options of the diffs printed to the reporter's output stream.
+func (r *Runner) diffOptions(rp *reporter) diff.Options {
+	opts := r.diff
+
+	width, terminal := rp.terminalWidth()
+
+	switch r.color {
+	case colorAlways:
+		opts.Color = true
+
+	case colorNever:
+		opts.Color = false
+
+	default:
+		opts.Color = terminal && os.Getenv("NO_COLOR") == ""
+	}
+
+	// The width of the side-by-side style defaults to diff.DefaultWidth when the output is not a terminal.
+	opts.Width = width
+
+	return opts
+}
+
+// patchFile collects the diffs of the changed files, and writes them as a single patch,
+// that can be applied with `git apply` or `patch -p1`.
+type patchFile struct {
+	file    string
+	context int
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func newPatchFile(file string, context int) *patchFile {
+	if file == "" {
+		return nil
+	}
+
+	return &patchFile{file: file, context: context}
+}
+
+// add adds the diff of a changed file to the patch.
+func (p *patchFile) add(filename string, src, res []byte) {
+	patch := diff.Unified(filename, src, res, p.context)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf.Write(patch)
+}
+
+// write writes the patch file, once all the files are processed.
+func (p *patchFile) write() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return os.WriteFile(p.file, p.buf.Bytes(), 0o644)
+}
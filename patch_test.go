@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golines/internal/diff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runner_run_patchFile(t *testing.T) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found")
+	}
+
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"test1.go": testFiles["test1.go"],
+		"test2.go": testFiles["test1.go"],
+		"test3.go": "package main\n",
+	}, root)
+
+	t.Chdir(root)
+
+	patchFile := filepath.Join(t.TempDir(), "golines.patch")
+
+	runner := NewRunner()
+	runner.args = []string{"."}
+	runner.dryRun = true
+	runner.color = colorAlways
+	runner.patch = newPatchFile(patchFile, diff.DefaultContext)
+	runner.selector = newFileSelector(nil, nil, nil, false)
+
+	var stdout bytes.Buffer
+
+	s := newSequencer(1, &stdout, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	// The diffs printed with --dry-run are colored, not the patch.
+	assert.Contains(t, stdout.String(), "\033[")
+
+	patch, err := os.ReadFile(patchFile)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(patch), "\033[")
+	assert.Equal(t, 2, strings.Count(string(patch), "diff --git "))
+
+	cmd := exec.Command(git, "apply", patchFile)
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	runner.dryRun = false
+	runner.listFiles = true
+	runner.patch = nil
+
+	stdout.Reset()
+
+	s = newSequencer(1, &stdout, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	// The patched files are already shortened.
+	assert.Empty(t, stdout.String())
+}
+
+func Test_runner_diffOptions(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		color    string
+		noColor  string
+		expected bool
+	}{
+		{desc: "auto without a terminal", color: colorAuto, expected: false},
+		{desc: "always", color: colorAlways, expected: true},
+		{desc: "always with NO_COLOR", color: colorAlways, noColor: "1", expected: true},
+		{desc: "never", color: colorNever, expected: false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Setenv("NO_COLOR", test.noColor)
+
+			runner := &Runner{
+				color: test.color,
+				diff:  diff.Options{Context: 2},
+			}
+
+			s := newSequencer(1, &bytes.Buffer{}, os.Stderr)
+
+			s.Add(0, func(rp *reporter) error {
+				opts := runner.diffOptions(rp)
+
+				assert.Equal(t, test.expected, opts.Color)
+				assert.Equal(t, 2, opts.Context)
+
+				return nil
+			})
+
+			require.Equal(t, 0, s.GetExitCode())
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"golang.org/x/term"
+)
+
+// exitCodeChanged is the exit code when some files are (or would be) changed, with the setExitStatus option.
+// The errors always set the exit code to 2.
+const exitCodeChanged = 1
+
+// markChecked records a processed file.
+func (r *reporter) markChecked() {
+	r.getState().checked++
+}
+
+// markChanged records a file that is (or would be) changed.
+// With setExitStatus, the exit code becomes exitCodeChanged, unless an error already set it.
+func (r *reporter) markChanged(setExitStatus bool) {
+	st := r.getState()
+	st.changed++
+
+	if setExitStatus && st.exitCode == 0 {
+		st.exitCode = exitCodeChanged
+	}
+}
+
+// AddSummary prints a summary line with the counts of files checked, changed, and errored
+// to s's error stream, after the output of any previously-added tasks.
+func (s *sequencer) AddSummary() {
+	s.Add(0, func(r *reporter) error {
+		st := r.getState()
+
+		r.Warnf("golines: %d files checked, %d changed, %d errored\n", st.checked, st.changed, st.errored)
+
+		return nil
+	})
+}
+
+// terminalWidth reports whether the output stream is a terminal, and its width.
+func (r *reporter) terminalWidth() (int, bool) {
+	f, ok := r.getState().out.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0, true
+	}
+
+	return width, true
+}
+
+// runTask runs the function of a sequencer task, turning a panic into an error with its stack,
+// so the task is reported and the other tasks still finish.
+// For a task processing a file, the path prefixes the error, and the file is left unchanged.
+func runTask(path string, f func(*reporter) error, r *reporter) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("internal error: panic: %v\n\n%s", p, debug.Stack())
+
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}()
+
+	return f(r)
+}
@@ -1,6 +1,7 @@
 package shorten
 
 import (
+	"log/slog"
 	"strings"
 
 	"github.com/golangci/golines/shorten/internal"
@@ -20,12 +21,12 @@ func (s *Shortener) annotateLongLines(lines []string) ([]string, int) {
 
 	prevLen := -1
 
-	for _, line := range lines {
+	for i, line := range lines {
 		length := internal.LineLength(line, s.config.TabLen)
 
 		if prevLen > -1 {
-			if length <= s.config.MaxLen {
-				// Shortening successful, remove previous annotation
+			if length <= s.config.MaxLen || s.isExempt(line, length) {
+				// Shortening successful (or only an exempt token is left), remove previous annotation
 				annotatedLines = annotatedLines[:len(annotatedLines)-1]
 			} else if length < prevLen {
 				// Replace annotation with a new length
@@ -34,12 +35,16 @@ func (s *Shortener) annotateLongLines(lines []string) ([]string, int) {
 				nbLinesToShorten++
 			}
 		} else if !comments.Is(line) && length > s.config.MaxLen {
-			annotatedLines = append(
-				annotatedLines,
-				annotation.Create(length),
-			)
+			if s.isExempt(line, length) {
+				s.logger.Debug("line exempt from shortening", slog.Int("line", i+1))
+			} else {
+				annotatedLines = append(
+					annotatedLines,
+					annotation.Create(length),
+				)
 
-			nbLinesToShorten++
+				nbLinesToShorten++
+			}
 		}
 
 		annotatedLines = append(annotatedLines, line)
@@ -49,6 +54,52 @@ func (s *Shortener) annotateLongLines(lines []string) ([]string, int) {
 	return annotatedLines, nbLinesToShorten
 }
 
+// isExempt determines whether the excess length of the provided line comes from
+// a single token matching one of the configured exempt patterns,
+// e.g., a URL, a long string literal, or an import path.
+// Such lines can't be shortened, so it's pointless to annotate them.
+func (s *Shortener) isExempt(line string, length int) bool {
+	for _, pattern := range s.config.ExemptPatterns {
+		for _, loc := range pattern.FindAllStringIndex(line, -1) {
+			width := internal.LineLength(line[loc[0]:loc[1]], s.config.TabLen)
+
+			if length-width <= s.config.MaxLen {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// reportLongLines logs the lines that are still longer than the configured target length
+// once the shortening is done.
+// It returns the number of these lines, except the exempt ones, and the number of them that aren't comments.
+func (s *Shortener) reportLongLines(content []byte) (overLimit, codeOverLimit int) {
+	for i, line := range strings.Split(string(content), "\n") {
+		length := internal.LineLength(line, s.config.TabLen)
+		if length <= s.config.MaxLen {
+			continue
+		}
+
+		if s.isExempt(line, length) {
+			s.logger.Debug("exempt line", slog.Int("line", i+1), slog.Int("length", length))
+
+			continue
+		}
+
+		s.logger.Debug("unshortenable line", slog.Int("line", i+1), slog.Int("length", length))
+
+		overLimit++
+
+		if !comments.Is(line) {
+			codeOverLimit++
+		}
+	}
+
+	return overLimit, codeOverLimit
+}
+
 // removeAnnotations removes all comments added by the annotateLongLines function above.
 func removeAnnotations(content []byte) []byte {
 	var cleanedLines []string
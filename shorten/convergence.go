@@ -0,0 +1,103 @@
+package shorten
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConvergenceError reports that the shortening rounds of a file don't converge,
+// with the CheckIdempotency option.
+type ConvergenceError struct {
+	// Filename The name of the file
+	Filename string
+
+	// Rounds The number of rounds run
+	Rounds int
+
+	// MaxRounds The maximum number of rounds
+	MaxRounds int
+
+	// Period The number of rounds after which the annotated content comes back (0 if the round limit was hit)
+	Period int
+}
+
+func (e *ConvergenceError) Error() string {
+	var reason string
+	if e.Period > 0 {
+		reason = fmt.Sprintf("the rounds cycle with a period of %d rounds, after %d rounds", e.Period, e.Rounds)
+	} else {
+		reason = fmt.Sprintf("the round limit (%d) was hit", e.MaxRounds)
+	}
+
+	return withFilename(e.Filename, "the shortening doesn't converge: "+reason)
+}
+
+// IdempotencyError reports that processing the output of the shortener a second time changes it,
+// with the CheckIdempotency option.
+type IdempotencyError struct {
+	// Filename The name of the file
+	Filename string
+
+	// Line The first line that differs (1-based)
+	Line int
+
+	// Output The line in the output
+	Output string
+
+	// Reprocessed The line in the output processed a second time
+	Reprocessed string
+}
+
+func (e *IdempotencyError) Error() string {
+	return withFilename(e.Filename, fmt.Sprintf(
+		"the shortening is not idempotent: line %d changes when the output is processed again: %q -> %q",
+		e.Line, e.Output, e.Reprocessed,
+	))
+}
+
+// checkIdempotency processes the output a second time, and reports the first difference.
+func (s *Shortener) checkIdempotency(filename string, output []byte) error {
+	config := *s.config
+	config.CheckIdempotency = false
+	config.Verify = false
+	config.Trace = false
+	config.DotFile = ""
+
+	result, err := s.nested(&config).ProcessFile(filename, output)
+	if err != nil {
+		return fmt.Errorf("error processing the output again: %w", err)
+	}
+
+	if string(result.Content) == string(output) {
+		return nil
+	}
+
+	outputLines := strings.Split(string(output), "\n")
+	reprocessedLines := strings.Split(string(result.Content), "\n")
+
+	for i := range max(len(outputLines), len(reprocessedLines)) {
+		var outputLine, reprocessedLine string
+
+		if i < len(outputLines) {
+			outputLine = outputLines[i]
+		}
+
+		if i < len(reprocessedLines) {
+			reprocessedLine = reprocessedLines[i]
+		}
+
+		if outputLine != reprocessedLine {
+			return &IdempotencyError{Filename: filename, Line: i + 1, Output: outputLine, Reprocessed: reprocessedLine}
+		}
+	}
+
+	return nil
+}
+
+func withFilename(filename, message string) string {
+	if filename == "" {
+		return message
+	}
+
+	return filename + ": " + message
+}
@@ -0,0 +1,191 @@
+package shorten
+
+import (
+	"slices"
+
+	"github.com/dave/dst"
+	"github.com/golangci/golines/shorten/internal/annotation"
+	"github.com/golangci/golines/shorten/internal/comments"
+)
+
+// keepDirectives moves the trailing directives (e.g., `//nolint:errcheck // reason`) of a split statement
+// to the end of its first line, so they still apply to the statement they were written for.
+func keepDirectives(stmt dst.Stmt) {
+	trailing := trailingDecorations(stmt)
+	if trailing == nil {
+		return
+	}
+
+	var directives, others []string
+
+	for _, deco := range *trailing {
+		if comments.IsDirective(deco) && !annotation.Is(deco) {
+			directives = append(directives, deco)
+		} else {
+			others = append(others, deco)
+		}
+	}
+
+	if len(directives) == 0 {
+		return
+	}
+
+	firstLine, broken := firstLineEnd(stmt)
+	if !broken {
+		return
+	}
+
+	if firstLine == nil {
+		// The directives can't be moved to the first line (e.g., a method chain split on the dots),
+		// at least keep them on the last line of the statement, instead of on their own line.
+		if last := lastExpr(stmt); last != nil {
+			last.Decorations().After = dst.None
+		}
+
+		return
+	}
+
+	firstLine.Append(directives...)
+	trailing.Replace(others...)
+}
+
+// trailingDecorations returns the decorations printed at the end of the first line of a statement
+// before it's split.
+func trailingDecorations(stmt dst.Stmt) *dst.Decorations {
+	switch st := stmt.(type) {
+	case *dst.AssignStmt, *dst.ExprStmt, *dst.ReturnStmt, *dst.DeferStmt, *dst.GoStmt:
+		return &st.Decorations().End
+
+	case *dst.IfStmt:
+		if st.Body != nil {
+			return &st.Body.Decs.Lbrace
+		}
+	}
+
+	return nil
+}
+
+// lastExpr returns the last expression of a statement.
+func lastExpr(stmt dst.Stmt) dst.Expr {
+	var exprs []dst.Expr
+
+	switch st := stmt.(type) {
+	case *dst.AssignStmt:
+		exprs = st.Rhs
+
+	case *dst.ExprStmt:
+		exprs = []dst.Expr{st.X}
+
+	case *dst.ReturnStmt:
+		exprs = st.Results
+
+	case *dst.DeferStmt:
+		exprs = []dst.Expr{st.Call}
+
+	case *dst.GoStmt:
+		exprs = []dst.Expr{st.Call}
+	}
+
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	return exprs[len(exprs)-1]
+}
+
+// firstLineEnd returns the decorations printed at the end of the first line of a node split over several lines.
+// The boolean reports whether a line break was found in the node:
+// when it's true, the decorations are nil if nothing can be put at the end of the first line
+// (e.g., a method chain split on the dots).
+func firstLineEnd(node dst.Node) (*dst.Decorations, bool) {
+	switch n := node.(type) {
+	case *dst.AssignStmt:
+		return firstLineEndIn(nil, slices.Concat(n.Lhs, n.Rhs)...)
+
+	case *dst.ExprStmt:
+		return firstLineEndIn(nil, n.X)
+
+	case *dst.ReturnStmt:
+		return firstLineEndIn(nil, n.Results...)
+
+	case *dst.DeferStmt:
+		return firstLineEndIn(nil, n.Call)
+
+	case *dst.GoStmt:
+		return firstLineEndIn(nil, n.Call)
+
+	case *dst.IfStmt:
+		if n.Init != nil {
+			if decs, broken := firstLineEndIn(nil, n.Init); broken {
+				return decs, true
+			}
+		}
+
+		return firstLineEndIn(nil, n.Cond)
+
+	case *dst.CallExpr:
+		if decs, broken := firstLineEndIn(nil, n.Fun); broken {
+			return decs, true
+		}
+
+		return firstLineEndIn(&n.Decs.Lparen, n.Args...)
+
+	case *dst.CompositeLit:
+		if n.Type != nil {
+			if decs, broken := firstLineEndIn(nil, n.Type); broken {
+				return decs, true
+			}
+		}
+
+		return firstLineEndIn(&n.Decs.Lbrace, n.Elts...)
+
+	case *dst.BinaryExpr:
+		if decs, broken := firstLineEndIn(nil, n.X); broken {
+			return decs, true
+		}
+
+		return firstLineEndIn(&n.Decs.Op, n.Y)
+
+	case *dst.ParenExpr:
+		return firstLineEndIn(&n.Decs.Lparen, n.X)
+
+	case *dst.KeyValueExpr:
+		return firstLineEndIn(nil, n.Key, n.Value)
+
+	case *dst.SelectorExpr:
+		return firstLineEndIn(nil, n.X)
+
+	case *dst.StarExpr:
+		return firstLineEndIn(nil, n.X)
+
+	case *dst.UnaryExpr:
+		return firstLineEndIn(nil, n.X)
+	}
+
+	return nil, false
+}
+
+// firstLineEndIn looks for the first line break in a list of nodes.
+// The opening decorations are the ones printed before the first node, e.g., after a parenthesis.
+func firstLineEndIn[T dst.Node](opening *dst.Decorations, nodes ...T) (*dst.Decorations, bool) {
+	for i, node := range nodes {
+		if node.Decorations().Before == dst.NewLine || node.Decorations().Before == dst.EmptyLine {
+			if i == 0 {
+				return opening, true
+			}
+
+			// A comment after a comma can't be represented with the decorations.
+			return nil, true
+		}
+
+		if decs, broken := firstLineEnd(node); broken {
+			return decs, true
+		}
+
+		if node.Decorations().After == dst.NewLine || node.Decorations().After == dst.EmptyLine {
+			return nil, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,154 @@
+package shorten
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"strconv"
+	"strings"
+
+	"github.com/golangci/golines/internal/diff"
+)
+
+// Stage is a stage of the processing of a file.
+type Stage string
+
+// Stages of the processing of a file.
+const (
+	// StageBaseFormatter is the base formatter (e.g., goimports), run before the shortener.
+	StageBaseFormatter Stage = "base_formatter"
+
+	// StageFormat is the initial formatting, before the shortening rounds.
+	StageFormat Stage = "format"
+
+	// StageParse is the parsing of the annotated content, in each shortening round.
+	StageParse Stage = "parse"
+
+	// StagePrint is the printing of the shortened syntax tree, in each shortening round.
+	StagePrint Stage = "print"
+
+	// StageFinalFormat is the final formatting, after the shortening rounds.
+	StageFinalFormat Stage = "final_format"
+)
+
+// description returns the description of the failure of a stage.
+func (s Stage) description() string {
+	switch s {
+	case StageBaseFormatter:
+		return "error running the base formatter"
+	case StageFormat:
+		return "error formatting source"
+	case StageParse:
+		return "error parsing the annotated source"
+	case StagePrint:
+		return "error printing source"
+	case StageFinalFormat:
+		return "error formatting the shortened source"
+	default:
+		return "error in stage " + string(s)
+	}
+}
+
+// Error is the failure of a stage of the processing of a file.
+// The position is the one of the first syntax error (if any), in the content passed to the shortener:
+// the positions in the later stages are mapped back through the initial formatting and the rounds
+// (see Remap).
+type Error struct {
+	// Stage The stage that failed
+	Stage Stage
+
+	// Filename The name of the file
+	Filename string
+
+	// Line The line of the error (0 if unknown)
+	Line int
+
+	// Column The column of the error (0 if unknown, e.g., on a line changed by the formatting)
+	Column int
+
+	// Err The error of the stage
+	Err error
+}
+
+// NewError returns the failure of a stage, at the position of its first syntax error (if any).
+func NewError(stage Stage, filename string, err error) *Error {
+	e := &Error{Stage: stage, Filename: filename, Err: err}
+
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		e.Line, e.Column = list[0].Pos.Line, list[0].Pos.Column
+	}
+
+	return e
+}
+
+// Remap moves the position of the error from the result of a transformation (e.g., a formatter) to its content.
+// A line changed by the transformation is mapped to the line it replaces
+// (e.g., the line of the original statement for a split statement), without column.
+func (e *Error) Remap(content, result []byte) *Error {
+	if e.Line <= 0 {
+		return e
+	}
+
+	lines := diff.MapLines(content, result)
+	if e.Line > len(lines) {
+		return e
+	}
+
+	line := lines[e.Line-1] + 1
+
+	if lineAt(content, line) != lineAt(result, e.Line) {
+		e.Column = 0
+	}
+
+	e.Line = line
+
+	return e
+}
+
+// lineAt returns a (1-based) line of a content.
+func lineAt(content []byte, line int) string {
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return lines[line-1]
+}
+
+func (e *Error) Error() string {
+	message := e.Err.Error()
+
+	var list scanner.ErrorList
+	if errors.As(e.Err, &list) && len(list) > 0 {
+		message = list[0].Msg
+
+		if len(list) > 1 {
+			message += fmt.Sprintf(" (and %d more errors)", len(list)-1)
+		}
+	}
+
+	position := e.Filename
+
+	if e.Line > 0 {
+		position = strconv.Itoa(e.Line)
+
+		if e.Column > 0 {
+			position += ":" + strconv.Itoa(e.Column)
+		}
+
+		if e.Filename != "" {
+			position = e.Filename + ":" + position
+		}
+	}
+
+	if position == "" {
+		return e.Stage.description() + ": " + message
+	}
+
+	return e.Stage.description() + ": " + position + ": " + message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
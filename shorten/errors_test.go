@@ -0,0 +1,119 @@
+package shorten
+
+import (
+	"errors"
+	"go/scanner"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_ProcessFile_error(t *testing.T) {
+	content := []byte("package main\n\nfunc main( {\n}\n")
+
+	_, err := NewShortener(nil).ProcessFile("main.go", content)
+	require.EqualError(t, err, "error formatting source: main.go:3:12: expected ')', found '{' (and 1 more errors)")
+
+	var stageErr *Error
+	require.ErrorAs(t, err, &stageErr)
+
+	assert.Equal(t, StageFormat, stageErr.Stage)
+	assert.Equal(t, "main.go", stageErr.Filename)
+	assert.Equal(t, 3, stageErr.Line)
+	assert.Equal(t, 12, stageErr.Column)
+
+	var list scanner.ErrorList
+	assert.ErrorAs(t, err, &list)
+}
+
+func TestNewError(t *testing.T) {
+	syntaxErr := scanner.ErrorList{{Pos: token.Position{Line: 4, Column: 3}, Msg: "expected ';'"}}
+
+	testCases := []struct {
+		desc     string
+		stage    Stage
+		filename string
+		err      error
+		expected string
+		line     int
+	}{
+		{
+			desc:     "syntax error",
+			stage:    StageFinalFormat,
+			filename: "main.go",
+			err:      syntaxErr,
+			expected: "error formatting the shortened source: main.go:4:3: expected ';'",
+			line:     4,
+		},
+		{
+			desc:     "without filename",
+			stage:    StageFormat,
+			err:      syntaxErr,
+			expected: "error formatting source: 4:3: expected ';'",
+			line:     4,
+		},
+		{
+			desc:     "other error",
+			stage:    StageBaseFormatter,
+			filename: "main.go",
+			err:      errors.New("exit status 2"),
+			expected: "error running the base formatter: main.go: exit status 2",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := NewError(test.stage, test.filename, test.err)
+
+			require.EqualError(t, err, test.expected)
+
+			assert.Equal(t, test.line, err.Line)
+			assert.Equal(t, test.err, errors.Unwrap(err))
+		})
+	}
+}
+
+func TestError_Remap(t *testing.T) {
+	content := []byte("package main\n\nfunc main() {\n\tf(aaa, bbb)\n\tg()\n}\n")
+	result := []byte("package main\n\n//golines:shorten:120\nfunc main() {\n\tf(\n\t\taaa,\n\t\tbbb,\n\t)\n\tg()\n}\n")
+
+	testCases := []struct {
+		desc     string
+		line     int
+		expected string
+	}{
+		{
+			desc:     "unchanged line",
+			line:     9,
+			expected: "error parsing the annotated source: main.go:5:3: expected ';'",
+		},
+		{
+			desc:     "split statement",
+			line:     7,
+			expected: "error parsing the annotated source: main.go:4: expected ';'",
+		},
+		{
+			desc:     "unknown line",
+			expected: "error parsing the annotated source: main.go: expected ';'",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var syntaxErr error = scanner.ErrorList{{Pos: token.Position{Line: test.line, Column: 3}, Msg: "expected ';'"}}
+			if test.line == 0 {
+				syntaxErr = errors.New("expected ';'")
+			}
+
+			err := NewError(StageParse, "main.go", syntaxErr).Remap(content, result)
+
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package shorten
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/golangci/golines/internal/diff"
+	"github.com/golangci/golines/shorten/internal"
+	"github.com/golangci/golines/shorten/internal/annotation"
+	"github.com/golangci/golines/shorten/internal/comments"
+	"github.com/golangci/golines/shorten/internal/graph"
+)
+
+// Explanation describes how the shortener handles a line of a file.
+type Explanation struct {
+	// File The name of the file
+	File string
+
+	// Line The line of the file
+	Line int
+
+	// FormattedLine The line after the initial formatting, the one of the positions of the rounds
+	FormattedLine int
+
+	// Text The content of the line, after the initial formatting
+	Text string
+
+	// Length The length of the line
+	Length int
+
+	// MaxLen The target maximum line length
+	MaxLen int
+
+	// Path The path of the syntax tree nodes from the file to the smallest node spanning the whole line,
+	// e.g., `File`, `Decls: FuncDecl`, `Body: BlockStmt`, `List: ExprStmt`, `X: CallExpr`
+	Path []string
+
+	// Rounds The shortening rounds of the statement (or declaration) of the line
+	Rounds []ExplainedRound
+
+	// Reason The reason why the shortening of the line stopped
+	Reason string
+}
+
+// ExplainedRound is a shortening round of the statement (or declaration) of an explained line.
+type ExplainedRound struct {
+	// Round The shortening round, starting at 0
+	Round int
+
+	// Events The decisions on the nodes of the statement
+	Events []TraceEvent
+
+	// Text The statement at the end of the round
+	Text string
+
+	// Fits Whether all the lines of the statement fit in the maximum length at the end of the round
+	Fits bool
+}
+
+// Explain shortens the provided golang file content bytes,
+// and describes how a line is handled: its syntax tree path, the rules applied in each round,
+// the intermediate text, and the reason why the shortening stopped.
+// The line is a line of the provided content: it's mapped to the content after the initial formatting.
+func (s *Shortener) Explain(filename string, content []byte, line int) (*Explanation, error) {
+	formatted, err := format.Source(content)
+	if err != nil {
+		return nil, NewError(StageFormat, filename, err)
+	}
+
+	if nbLines := strings.Count(strings.TrimSuffix(string(content), "\n"), "\n") + 1; line < 1 || line > nbLines {
+		return nil, fmt.Errorf("line %d out of range: the file has %d lines", line, nbLines)
+	}
+
+	index, ok := diff.MapLine(content, formatted, line-1)
+	if !ok {
+		return nil, fmt.Errorf("line %d is removed by the formatting", line)
+	}
+
+	lines := strings.Split(string(formatted), "\n")
+
+	explanation := &Explanation{
+		File:          filename,
+		Line:          line,
+		FormattedLine: index + 1,
+		Text:          lines[index],
+		Length:        internal.LineLength(lines[index], s.config.TabLen),
+		MaxLen:        s.config.MaxLen,
+	}
+
+	config := *s.config
+	config.Trace = true
+
+	c := s.nested(&config)
+	c.explainer = &explainer{line: explanation.FormattedLine, explanation: explanation}
+
+	result, err := c.ProcessFile(filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation.Reason = s.stopReason(explanation, result.Stats)
+
+	return explanation, nil
+}
+
+// stopReason returns the reason why the shortening of an explained line stopped.
+func (s *Shortener) stopReason(explanation *Explanation, stats Stats) string {
+	var events, unsupported int
+
+	for _, round := range explanation.Rounds {
+		for _, event := range round.Events {
+			events++
+
+			if event.Rule == RuleUnsupported {
+				unsupported++
+			}
+		}
+	}
+
+	switch {
+	case comments.Is(explanation.Text):
+		if s.config.ShortenComments {
+			return "comment lines are wrapped by the comment shortener, after the shortening rounds"
+		}
+
+		return "comment lines are only shortened with the option to shorten comments"
+
+	case explanation.Length <= s.config.MaxLen && events == 0:
+		return fmt.Sprintf("the line fits in the maximum length (%d <= %d)", explanation.Length, s.config.MaxLen)
+
+	case s.isExempt(explanation.Text, explanation.Length):
+		return "the excess length of the line comes from a token matching an exempt pattern"
+
+	case len(explanation.Rounds) > 0 && explanation.Rounds[len(explanation.Rounds)-1].Fits:
+		return fmt.Sprintf("the lines fit in the maximum length after round %d",
+			explanation.Rounds[len(explanation.Rounds)-1].Round)
+
+	case stats.MaxRoundsHit:
+		return fmt.Sprintf("the maximum number of rounds (%d) was hit", s.maxRounds())
+
+	case events == 0:
+		return "no shortening rule applies to the nodes of the line"
+
+	case events == unsupported:
+		return "the nodes of the line can't be shortened"
+
+	default:
+		return "the rules can't shorten the line further: its length doesn't decrease anymore"
+	}
+}
+
+// explainer tracks the statement (or declaration) of an explained line through the shortening rounds.
+type explainer struct {
+	// line is the line of the statement at the start of the current round (without the annotations).
+	line int
+
+	// nodeType is the type of the tracked node (e.g., ExprStmt).
+	nodeType string
+
+	// target is the tracked node in the current round.
+	target dst.Node
+
+	// start and end are the lines of the tracked node at the start of the current round
+	// (without the annotations).
+	start, end int
+
+	explanation *Explanation
+}
+
+// startRound locates the tracked node in the file parsed for a round.
+func (e *explainer) startRound(t *tracer, file *dst.File) {
+	e.target = nil
+
+	// The annotated line of the tracked line.
+	annotated := annotatedLine(t.lines, e.line)
+	if annotated == 0 {
+		return
+	}
+
+	pos := nodePositions{fset: t.decorator.Fset, nodes: t.decorator.Ast.Nodes}
+
+	root := graph.NodeToGraphNode(file)
+
+	if e.nodeType == "" {
+		// First round: the path to the line, and the statement to track.
+		first, last := lineBounds(t.lines[annotated-1], t.decorator.Ast.Nodes[file], t.decorator.Fset, annotated)
+
+		path, nodes := pathToLine(root, pos, annotated, first, last)
+		e.explanation.Path = path
+
+		// The comment lines are not handled by the shortening rounds.
+		if comments.Is(t.lines[annotated-1]) {
+			e.line = 0
+
+			return
+		}
+
+		for _, n := range nodes {
+			switch n.(type) {
+			case dst.Stmt, dst.Decl, dst.Spec:
+				e.target = n
+			}
+		}
+
+		if e.target == nil {
+			return
+		}
+
+		e.nodeType = fmt.Sprintf("%T", e.target)
+	} else {
+		e.target = findNode(root, pos, e.nodeType, annotated)
+		if e.target == nil {
+			return
+		}
+	}
+
+	startLine, _, endLine, _ := pos.lines(e.target)
+
+	e.start = startLine - countAnnotations(t.lines[:startLine-1])
+	e.end = endLine - countAnnotations(t.lines[:min(endLine, len(t.lines))])
+}
+
+// endRound records the decisions on the tracked node, and its text at the end of the round.
+func (e *explainer) endRound(t *tracer, positions *outputPositions, lines []string) {
+	if e.target == nil {
+		return
+	}
+
+	round := ExplainedRound{Round: t.round, Fits: true}
+
+	for _, event := range t.events {
+		if event.Round == t.round && event.Line >= e.start && event.Line <= e.end {
+			round.Events = append(round.Events, event)
+		}
+	}
+
+	if start, end, ok := positions.lines(e.target); ok {
+		var text []string
+
+		for _, line := range lines[min(start-1, len(lines)):min(end, len(lines))] {
+			if annotation.Is(line) {
+				continue
+			}
+
+			text = append(text, line)
+
+			if internal.LineLength(line, t.tabLen) > t.maxLen {
+				round.Fits = false
+			}
+		}
+
+		round.Text = strings.Join(text, "\n")
+
+		// The annotation lines are the only ones changed between the rounds.
+		e.line = start - countAnnotations(lines[:min(start-1, len(lines))])
+	}
+
+	e.explanation.Rounds = append(e.explanation.Rounds, round)
+}
+
+// annotatedLine returns the line of the annotated lines matching a line without the annotations,
+// or 0 if out of range.
+func annotatedLine(lines []string, line int) int {
+	var n int
+
+	for i, l := range lines {
+		if annotation.Is(l) {
+			continue
+		}
+
+		n++
+
+		if n == line {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+// nodePositions gives the positions of the dst nodes, from the ast nodes they are decorated from.
+type nodePositions struct {
+	fset  *token.FileSet
+	nodes map[dst.Node]ast.Node
+}
+
+// lines returns the line and column of the start and the end of a node,
+// or zeros for the nodes without position.
+func (p nodePositions) lines(node dst.Node) (startLine, startCol, endLine, endCol int) {
+	n, ok := p.nodes[node]
+	if !ok || !n.Pos().IsValid() || !n.End().IsValid() {
+		return 0, 0, 0, 0
+	}
+
+	start, end := p.fset.Position(n.Pos()), p.fset.Position(n.End())
+
+	return start.Line, start.Column, end.Line, end.Column
+}
+
+// lineBounds returns the columns of the first and the last characters of the code of a line,
+// excluding the indentation, and the trailing comments.
+func lineBounds(text string, file ast.Node, fset *token.FileSet, line int) (first, last int) {
+	code := text
+
+	if f, ok := file.(*ast.File); ok {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				pos := fset.Position(c.Pos())
+				if pos.Line == line && pos.Column-1 < len(code) {
+					code = code[:pos.Column-1]
+				}
+			}
+		}
+	}
+
+	trimmed := strings.TrimRight(code, " \t")
+
+	return len(code) - len(strings.TrimLeft(code, " \t")) + 1, len(trimmed)
+}
+
+// pathToLine returns the path of the graph nodes from the root to the smallest node spanning a whole line,
+// with their dst nodes.
+func pathToLine(root *graph.Node, pos nodePositions, line, first, last int) ([]string, []dst.Node) {
+	return pathToRange(root, pos, line, first, line, last)
+}
+
+// pathToRange returns the path of the graph nodes from the root to the smallest node spanning a range
+// (from the character at startLine:startCol to the one at endLine:endCol), with their dst nodes.
+func pathToRange(root *graph.Node, pos nodePositions, startLine, startCol, endLine, endCol int) ([]string, []dst.Node) {
+	path := []string{root.Type}
+	nodes := []dst.Node{root.Node}
+
+	current := root
+
+	for {
+		var next *graph.Edge
+
+		for _, edge := range current.Edges {
+			nodeStartLine, nodeStartCol, nodeEndLine, nodeEndCol := pos.lines(edge.Dest.Node)
+			if nodeStartLine == 0 {
+				continue
+			}
+
+			if (nodeStartLine < startLine || nodeStartLine == startLine && nodeStartCol <= startCol) &&
+				(nodeEndLine > endLine || nodeEndLine == endLine && nodeEndCol-1 >= endCol) {
+				next = edge
+
+				break
+			}
+		}
+
+		if next == nil {
+			return path, nodes
+		}
+
+		label := next.Relationship + ": " + next.Dest.Type
+		if next.Dest.Value != "" {
+			label += " " + next.Dest.Value
+		}
+
+		path = append(path, label)
+		nodes = append(nodes, next.Dest.Node)
+
+		current = next.Dest
+	}
+}
+
+// findNode returns the outermost node of a type starting at a line.
+func findNode(root *graph.Node, pos nodePositions, nodeType string, line int) dst.Node {
+	if fmt.Sprintf("%T", root.Node) == nodeType {
+		if startLine, _, _, _ := pos.lines(root.Node); startLine == line {
+			return root.Node
+		}
+	}
+
+	for _, edge := range root.Edges {
+		startLine, _, endLine, _ := pos.lines(edge.Dest.Node)
+		if startLine == 0 || startLine > line || endLine < line {
+			continue
+		}
+
+		if n := findNode(edge.Dest, pos, nodeType, line); n != nil {
+			return n
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package shorten
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_Explain(t *testing.T) {
+	content := []byte(`package main
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+	_ = "a string literal that can't be shortened at all"
+	// a comment that is longer than the maximum line length
+	x := 1
+}
+`)
+
+	testCases := []struct {
+		desc     string
+		line     int
+		path     []string
+		rounds   []ExplainedRound
+		expected string
+	}{
+		{
+			desc: "shortened",
+			line: 4,
+			path: []string{"File", "Decls: FuncDecl", "Body: BlockStmt", "List: ExprStmt", "X: CallExpr"},
+			rounds: []ExplainedRound{
+				{
+					Round: 0,
+					Events: []TraceEvent{{
+						File:         "main.go",
+						Line:         4,
+						Column:       2,
+						Node:         "CallExpr",
+						Rule:         RuleCallArgs,
+						LengthBefore: 63,
+						LengthAfter:  23,
+						Converged:    true,
+					}},
+					Text: "\tfmt.Println(\n\t\t\"aaaaaaaaaaaa\",\n\t\t\"bbbbbbbbbbbb\",\n\t\t\"cccccccccccc\",\n\t)",
+					Fits: true,
+				},
+			},
+			expected: "the lines fit in the maximum length after round 0",
+		},
+		{
+			desc: "unsupported",
+			line: 5,
+			path: []string{"File", "Decls: FuncDecl", "Body: BlockStmt", "List: AssignStmt"},
+			rounds: []ExplainedRound{
+				{
+					Round: 0,
+					Events: []TraceEvent{{
+						File:         "main.go",
+						Line:         5,
+						Column:       6,
+						Node:         "BasicLit",
+						Rule:         RuleUnsupported,
+						LengthBefore: 57,
+						LengthAfter:  57,
+					}},
+					Text: "\t_ = \"a string literal that can't be shortened at all\"",
+				},
+			},
+			expected: "the nodes of the line can't be shortened",
+		},
+		{
+			desc:     "comment",
+			line:     6,
+			path:     []string{"File", "Decls: FuncDecl", "Body: BlockStmt"},
+			expected: "comment lines are only shortened with the option to shorten comments",
+		},
+		{
+			desc:     "short line",
+			line:     7,
+			path:     []string{"File", "Decls: FuncDecl", "Body: BlockStmt", "List: AssignStmt"},
+			rounds:   []ExplainedRound{{Round: 0, Text: "\tx := 1", Fits: true}},
+			expected: "the line fits in the maximum length (10 <= 40)",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := NewDefaultConfig()
+			config.MaxLen = 40
+
+			explanation, err := NewShortener(config).Explain("main.go", content, test.line)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.path, explanation.Path)
+			assert.Equal(t, test.rounds, explanation.Rounds)
+			assert.Equal(t, test.expected, explanation.Reason)
+		})
+	}
+}
+
+func TestShortener_Explain_outOfRange(t *testing.T) {
+	_, err := NewShortener(nil).Explain("main.go", []byte("package main\n\nfunc main() {}\n"), 10)
+	require.EqualError(t, err, "line 10 out of range: the file has 3 lines")
+}
+
+func TestShortener_Explain_unformatted(t *testing.T) {
+	// The blank lines are collapsed, and the statements are reindented, by the initial formatting.
+	content := []byte(`package main
+
+
+
+func main() {
+  x := 1
+  fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+`)
+
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+
+	explanation, err := NewShortener(config).Explain("main.go", content, 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, explanation.Line)
+	assert.Equal(t, 5, explanation.FormattedLine)
+	assert.Equal(t, "\tfmt.Println(\"aaaaaaaaaaaa\", \"bbbbbbbbbbbb\", \"cccccccccccc\")", explanation.Text)
+	assert.Equal(t, []string{"File", "Decls: FuncDecl", "Body: BlockStmt", "List: ExprStmt", "X: CallExpr"},
+		explanation.Path)
+
+	_, err = NewShortener(config).Explain("main.go", content, 3)
+	require.EqualError(t, err, "line 3 is removed by the formatting")
+}
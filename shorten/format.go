@@ -94,6 +94,9 @@ func (s *Shortener) formatStmt(stmt dst.Stmt, force bool) {
 
 	case *dst.CaseClause:
 		if shouldShorten {
+			s.count(ConstructConditions, 1)
+			s.trace(st, RuleCaseList)
+
 			for _, arg := range st.List {
 				arg.Decorations().After = dst.NewLine
 
@@ -147,12 +150,18 @@ func (s *Shortener) formatStmt(stmt dst.Stmt, force bool) {
 
 	default:
 		if shouldShorten {
+			s.trace(stmt, RuleUnsupported)
+
 			s.logger.Debug(
 				"got a statement type that can't be shortened",
 				slog.Any("stmt_type", stmtType),
 			)
 		}
 	}
+
+	if shouldShorten {
+		keepDirectives(stmt)
+	}
 }
 
 // formatExpr formats an AST expression node.
@@ -168,6 +177,9 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 				s.formatExpr(e.X, force, isChain)
 			} else {
 				e.Y.Decorations().Before = dst.NewLine
+
+				s.count(ConstructConditions, 1)
+				s.trace(e, RuleCondition)
 			}
 		} else {
 			s.formatExpr(e.X, shouldShorten, isChain)
@@ -181,11 +193,21 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 
 		if ok && shortenChildArgs &&
 			s.config.ChainSplitDots && (isChain || chainLength(e) > 1) {
+			if !isChain {
+				s.count(ConstructChains, 1)
+				s.trace(e, RuleChain)
+			}
+
 			e.Decorations().After = dst.NewLine
 
 			s.formatExprs(e.Args, false, true)
 			s.formatExpr(e.Fun, shouldShorten, true)
 		} else {
+			if shortenChildArgs && len(e.Args) > 0 {
+				s.count(ConstructCallArgs, 1)
+				s.trace(e, RuleCallArgs)
+			}
+
 			for i, arg := range e.Args {
 				if shortenChildArgs {
 					formatList(arg, i)
@@ -198,7 +220,10 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 		}
 
 	case *dst.CompositeLit:
-		if shouldShorten {
+		if shouldShorten && len(e.Elts) > 0 {
+			s.count(ConstructCompositeLits, 1)
+			s.trace(e, RuleCompositeLit)
+
 			for i, element := range e.Elts {
 				if i == 0 {
 					element.Decorations().Before = dst.NewLine
@@ -219,6 +244,10 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 		}
 
 	case *dst.InterfaceType:
+		if e.Methods == nil {
+			return
+		}
+
 		for _, method := range e.Methods.List {
 			if annotation.Has(method) {
 				s.formatExpr(method.Type, true, isChain)
@@ -233,7 +262,14 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 
 	case *dst.StructType:
 		if s.config.ReformatTags {
-			tags.FormatStructTags(e.Fields)
+			before := fieldTags(e.Fields)
+
+			tags.FormatStructTags(e.Fields, s.tagAlignment(), s.config.TagOrder)
+
+			if n := countChanged(before, fieldTags(e.Fields)); n > 0 {
+				s.count(ConstructTags, n)
+				s.trace(e, RuleStructTags)
+			}
 		}
 
 	case *dst.UnaryExpr:
@@ -241,6 +277,11 @@ func (s *Shortener) formatExpr(expr dst.Expr, force, isChain bool) {
 
 	default:
 		if shouldShorten {
+			// The identifiers are the leaves of the shortened expressions, not worth a trace.
+			if _, ok := e.(*dst.Ident); !ok {
+				s.trace(expr, RuleUnsupported)
+			}
+
 			s.logger.Debug(
 				"got an expression type that can't be shortened",
 				slog.Any("expr_type", reflect.TypeOf(e)),
@@ -263,6 +304,8 @@ func (s *Shortener) formatSpec(spec dst.Spec, force bool) {
 
 	default:
 		if shouldShorten {
+			s.trace(spec, RuleUnsupported)
+
 			s.logger.Debug(
 				"got a spec type that can't be shortened",
 				slog.Any("spec_type", reflect.TypeOf(sp)),
@@ -285,6 +328,15 @@ func (s *Shortener) formatExprs(exprs []dst.Expr, force, isChain bool) {
 
 // formatFieldList formats a field list in a function declaration.
 func (s *Shortener) formatFieldList(fieldList *dst.FieldList) {
+	if fieldList == nil {
+		return
+	}
+
+	if len(fieldList.List) > 0 {
+		s.count(ConstructSignatures, 1)
+		s.trace(fieldList, RuleSignature)
+	}
+
 	for i, field := range fieldList.List {
 		formatList(field, i)
 	}
@@ -0,0 +1,388 @@
+package shorten
+
+import (
+	"cmp"
+	"fmt"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/golangci/golines/internal/diff"
+	"github.com/golangci/golines/shorten/internal/graph"
+)
+
+var (
+	graphLineRe  = regexp.MustCompile(`^\d+$`)
+	graphBytesRe = regexp.MustCompile(`^(\d+)-(\d+)$`)
+	graphFuncRe  = regexp.MustCompile(`^(?:([\pL_][\pL\pN_]*)\.)?([\pL_][\pL\pN_]*)$`)
+)
+
+// graphScope is the part of a file rendered in the AST graphs.
+type graphScope struct {
+	// line is a line of the file.
+	line int
+
+	// start and end are a byte range of the file, end excluded.
+	start, end int
+
+	// recv and name are the receiver type (if any) and the name of a function.
+	recv, name string
+}
+
+// parseGraphScope parses the scope of the graphs:
+// a line (`123`), a byte range (`100-250`), or a function name (`myFunc` or `Type.Method`).
+// It returns nil for the whole file.
+func parseGraphScope(scope string) (*graphScope, error) {
+	switch {
+	case scope == "":
+		return nil, nil //nolint:nilnil // The whole file.
+
+	case graphLineRe.MatchString(scope):
+		line, err := strconv.Atoi(scope)
+		if err != nil || line < 1 {
+			return nil, fmt.Errorf("invalid graph scope %q: the lines start at 1", scope)
+		}
+
+		return &graphScope{line: line}, nil
+
+	case graphBytesRe.MatchString(scope):
+		match := graphBytesRe.FindStringSubmatch(scope)
+
+		start, errStart := strconv.Atoi(match[1])
+		end, errEnd := strconv.Atoi(match[2])
+
+		if errStart != nil || errEnd != nil || start >= end {
+			return nil, fmt.Errorf("invalid graph scope %q: expected a byte range start-end, with start < end", scope)
+		}
+
+		return &graphScope{start: start, end: end}, nil
+
+	case graphFuncRe.MatchString(scope):
+		match := graphFuncRe.FindStringSubmatch(scope)
+
+		return &graphScope{recv: match[1], name: match[2]}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid graph scope %q: expected a line, a byte range (start-end), "+
+			"or a function name (name or Type.Method)", scope)
+	}
+}
+
+// grapher writes the AST graphs of the rounds of a file.
+type grapher struct {
+	path     string
+	format   string
+	perRound bool
+	scope    *graphScope
+
+	// input is the content of the file, before the initial formatting:
+	// the lines and the byte ranges of the scope are mapped from it to the content of the first round.
+	input []byte
+
+	// index is the pre-order index of the node of a line or byte range scope, resolved in the first round.
+	index    int
+	nodeType string
+}
+
+// newGrapher returns a grapher writing the graphs of a file, given by its content before the initial formatting.
+// Returns nil if no file is configured.
+func newGrapher(config *Config, input []byte) (*grapher, error) {
+	if config.DotFile == "" {
+		return nil, nil //nolint:nilnil // The graphs are disabled.
+	}
+
+	scope, err := parseGraphScope(config.GraphScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grapher{
+		path:     config.DotFile,
+		format:   cmp.Or(config.GraphFormat, GraphFormatDot),
+		perRound: config.GraphPerRound,
+		scope:    scope,
+		input:    input,
+		index:    -1,
+	}, nil
+}
+
+// node returns the node of the scope in the file parsed for a round.
+// The lines are the lines of the round before the annotations, and the annotated lines the parsed ones.
+func (g *grapher) node(
+	round int,
+	lines, annotatedLines []string,
+	dec *decorator.Decorator,
+	file *dst.File,
+) (dst.Node, error) {
+	switch {
+	case g.scope == nil:
+		return file, nil
+
+	case g.scope.name != "":
+		if fn := findFunc(file, g.scope.recv, g.scope.name); fn != nil {
+			return fn, nil
+		}
+
+		return nil, fmt.Errorf("graph scope: no function %s", g.scopeName())
+
+	case g.index >= 0:
+		// The shortening only changes the decorations, so the nodes keep their order between the rounds.
+		n := nodeAt(file, g.index)
+		if n == nil || fmt.Sprintf("%T", n) != g.nodeType {
+			return nil, fmt.Errorf("graph scope: %s not found in round %d", g.nodeType, round)
+		}
+
+		return n, nil
+	}
+
+	// First round: the smallest declaration or statement enclosing the line or the byte range.
+	startLine, startCol, endLine, endCol, err := g.scopeRange(lines, annotatedLines, dec, file)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := nodePositions{fset: dec.Fset, nodes: dec.Ast.Nodes}
+
+	_, nodes := pathToRange(graph.NodeToGraphNode(file), pos, startLine, startCol, endLine, endCol)
+
+	var target dst.Node
+
+	for _, n := range nodes {
+		switch n.(type) {
+		case dst.Stmt, dst.Decl:
+			target = n
+		}
+	}
+
+	if target == nil {
+		return nil, fmt.Errorf("graph scope: no declaration or statement encloses %s", g.scopeName())
+	}
+
+	g.index = nodeIndex(file, target)
+	g.nodeType = fmt.Sprintf("%T", target)
+
+	return target, nil
+}
+
+// scopeRange returns the positions in the annotated lines
+// of the first and the last characters of a line or byte range scope.
+// The scope is mapped from the file to the lines of the round, through the initial formatting.
+func (g *grapher) scopeRange(
+	lines, annotatedLines []string,
+	dec *decorator.Decorator,
+	file *dst.File,
+) (startLine, startCol, endLine, endCol int, err error) {
+	content := []byte(strings.Join(lines, "\n"))
+
+	if g.scope.line > 0 {
+		if nbLines := strings.Count(strings.TrimSuffix(string(g.input), "\n"), "\n") + 1; g.scope.line > nbLines {
+			return 0, 0, 0, 0, fmt.Errorf("graph scope: line %d out of range: the file has %d lines",
+				g.scope.line, nbLines)
+		}
+
+		index, ok := diff.MapLine(g.input, content, g.scope.line-1)
+		if !ok {
+			return 0, 0, 0, 0, fmt.Errorf("graph scope: line %d is removed by the formatting", g.scope.line)
+		}
+
+		line := annotatedLine(annotatedLines, index+1)
+		first, last := lineBounds(annotatedLines[line-1], dec.Ast.Nodes[file], dec.Fset, line)
+
+		return line, first, line, last, nil
+	}
+
+	if g.scope.end > len(g.input) {
+		return 0, 0, 0, 0, fmt.Errorf("graph scope: byte range %d-%d out of range: the file has %d bytes",
+			g.scope.start, g.scope.end, len(g.input))
+	}
+
+	startLine, startCol, ok := mapOffset(g.input, content, g.scope.start, false)
+	if !ok {
+		return 0, 0, 0, 0, fmt.Errorf("graph scope: byte %d is removed by the formatting", g.scope.start)
+	}
+
+	endLine, endCol, ok = mapOffset(g.input, content, g.scope.end-1, true)
+	if !ok {
+		return 0, 0, 0, 0, fmt.Errorf("graph scope: byte %d is removed by the formatting", g.scope.end-1)
+	}
+
+	return annotatedLine(annotatedLines, startLine), startCol, annotatedLine(annotatedLines, endLine), endCol, nil
+}
+
+// mapOffset returns the position (line and column) in the content of a byte offset of the input,
+// through the formatting of the input.
+// The column follows the reindentation of the line;
+// on a line changed otherwise, it's the start (or the end) of the line.
+func mapOffset(input, content []byte, offset int, end bool) (line, column int, ok bool) {
+	tf := token.NewFileSet().AddFile("", -1, len(input))
+	tf.SetLinesForContent(input)
+
+	position := tf.Position(tf.Pos(offset))
+
+	index, ok := diff.MapLine(input, content, position.Line-1)
+	if !ok {
+		return 0, 0, false
+	}
+
+	inputLine, contentLine := lineAt(input, position.Line), lineAt(content, index+1)
+
+	inputText, contentText := strings.TrimLeft(inputLine, " \t"), strings.TrimLeft(contentLine, " \t")
+
+	switch {
+	case inputLine == contentLine:
+		column = position.Column
+
+	case inputText == contentText:
+		column = max(position.Column-(len(inputLine)-len(inputText))+(len(contentLine)-len(contentText)), 1)
+
+	case end:
+		column = max(len(contentLine), 1)
+
+	default:
+		column = 1
+	}
+
+	return index + 1, column, true
+}
+
+func (g *grapher) scopeName() string {
+	switch {
+	case g.scope.recv != "":
+		return g.scope.recv + "." + g.scope.name
+	case g.scope.name != "":
+		return g.scope.name
+	case g.scope.line > 0:
+		return "line " + strconv.Itoa(g.scope.line)
+	default:
+		return fmt.Sprintf("bytes %d-%d", g.scope.start, g.scope.end)
+	}
+}
+
+// filename returns the path of the graph of a round:
+// `name.round-N.ext` if there is a file per round, otherwise the configured path (overwritten at each round).
+func (g *grapher) filename(round int) string {
+	if !g.perRound {
+		return g.path
+	}
+
+	ext := filepath.Ext(g.path)
+
+	return fmt.Sprintf("%s.round-%d%s", strings.TrimSuffix(g.path, ext), round, ext)
+}
+
+// createDot writes the graph of a round.
+func (s *Shortener) createDot(
+	round int,
+	lines, annotatedLines []string,
+	dec *decorator.Decorator,
+	file *dst.File,
+) error {
+	node, err := s.grapher.node(round, lines, annotatedLines, dec, file)
+	if err != nil {
+		return err
+	}
+
+	filename := s.grapher.filename(round)
+
+	dotFile, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	defer dotFile.Close()
+
+	s.logger.Debug("writing dot file output", slog.String("file", filename), slog.String("format", s.grapher.format))
+
+	return graph.Create(node, s.grapher.format, dotFile)
+}
+
+// findFunc returns the declaration of a function, or of a method if the receiver type is set.
+func findFunc(file *dst.File, recv, name string) *dst.FuncDecl {
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*dst.FuncDecl)
+		if !ok || fn.Name.Name != name {
+			continue
+		}
+
+		if recv == "" && fn.Recv == nil || recv != "" && fn.Recv != nil && receiverName(fn.Recv) == recv {
+			return fn
+		}
+	}
+
+	return nil
+}
+
+// receiverName returns the name of the type of a receiver, without pointer and type parameters.
+func receiverName(recv *dst.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+
+	expr := recv.List[0].Type
+
+	for {
+		switch e := expr.(type) {
+		case *dst.StarExpr:
+			expr = e.X
+		case *dst.IndexExpr:
+			expr = e.X
+		case *dst.IndexListExpr:
+			expr = e.X
+		case *dst.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// nodeIndex returns the pre-order index of a node in a file, or -1 if not found.
+func nodeIndex(file *dst.File, node dst.Node) int {
+	index, i := -1, 0
+
+	dst.Inspect(file, func(n dst.Node) bool {
+		if n == nil || index >= 0 {
+			return false
+		}
+
+		if n == node {
+			index = i
+		}
+
+		i++
+
+		return index < 0
+	})
+
+	return index
+}
+
+// nodeAt returns the node at a pre-order index in a file.
+func nodeAt(file *dst.File, index int) dst.Node {
+	var (
+		node dst.Node
+		i    int
+	)
+
+	dst.Inspect(file, func(n dst.Node) bool {
+		if n == nil || node != nil {
+			return false
+		}
+
+		if i == index {
+			node = n
+		}
+
+		i++
+
+		return node == nil
+	})
+
+	return node
+}
@@ -0,0 +1,219 @@
+package shorten
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseGraphScope(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		scope    string
+		expected *graphScope
+		err      string
+	}{
+		{desc: "whole file", scope: ""},
+		{desc: "line", scope: "12", expected: &graphScope{line: 12}},
+		{desc: "byte range", scope: "100-250", expected: &graphScope{start: 100, end: 250}},
+		{desc: "function", scope: "myFunc", expected: &graphScope{name: "myFunc"}},
+		{desc: "method", scope: "Type.Method", expected: &graphScope{recv: "Type", name: "Method"}},
+		{desc: "line 0", scope: "0", err: `invalid graph scope "0": the lines start at 1`},
+		{
+			desc:  "empty byte range",
+			scope: "250-100",
+			err:   `invalid graph scope "250-100": expected a byte range start-end, with start < end`,
+		},
+		{
+			desc:  "invalid",
+			scope: "a.b.c",
+			err: `invalid graph scope "a.b.c": expected a line, a byte range (start-end), ` +
+				`or a function name (name or Type.Method)`,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			scope, err := parseGraphScope(test.scope)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, scope)
+		})
+	}
+}
+
+func TestShortener_ProcessFile_graph(t *testing.T) {
+	content := `package main
+
+type T struct{}
+
+func (t *T) Method() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+
+func main() {
+	x := 1
+	fmt.Println("aaaaaaaaaaaa", "a string literal longer than the maximum")
+}
+`
+
+	// The string literal is too long for any round, so all the rounds are run.
+	testCases := []struct {
+		desc     string
+		scope    string
+		expected string
+		err      string
+	}{
+		{desc: "whole file", expected: "File"},
+		{desc: "function", scope: "main", expected: "FuncDecl"},
+		{desc: "method", scope: "T.Method", expected: "FuncDecl"},
+		{desc: "line", scope: "11", expected: "ExprStmt"},
+		{desc: "line of a declaration", scope: "3", expected: "GenDecl"},
+		// The bytes of `x := 1` and of the start of the next statement.
+		{desc: "byte range", scope: "133-145", expected: "BlockStmt"},
+		{desc: "unknown function", scope: "other", err: "graph scope: no function other"},
+		{
+			desc:  "package clause",
+			scope: "1",
+			err:   "graph scope: no declaration or statement encloses line 1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dotFile := filepath.Join(t.TempDir(), "graph.dot")
+
+			config := NewDefaultConfig()
+			config.MaxLen = 40
+			config.DotFile = dotFile
+			config.GraphScope = test.scope
+			config.GraphPerRound = true
+
+			_, err := NewShortener(config).ProcessFile("main.go", []byte(content))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			_, err = os.Stat(dotFile)
+			require.ErrorIs(t, err, os.ErrNotExist)
+
+			for _, name := range []string{"graph.round-0.dot", "graph.round-1.dot"} {
+				graph, err := os.ReadFile(filepath.Join(filepath.Dir(dotFile), name))
+				require.NoError(t, err)
+
+				lines := strings.Split(string(graph), "\n")
+				require.Greater(t, len(lines), 1)
+
+				assert.True(t, strings.HasPrefix(lines[1], "\t"+test.expected+"_0_0["), lines[1])
+			}
+		})
+	}
+}
+
+func TestShortener_ProcessFile_graphUnformatted(t *testing.T) {
+	// The blank lines are collapsed, and the statements are reindented, by the initial formatting.
+	content := `package main
+
+
+
+func main() {
+  x := 1
+  fmt.Println("aaaaaaaaaaaa", "a string literal longer than the maximum")
+}
+`
+
+	start := strings.Index(content, "x := 1")
+
+	testCases := []struct {
+		desc     string
+		scope    string
+		expected string
+		err      string
+	}{
+		{desc: "line", scope: "7", expected: "ExprStmt"},
+		{desc: "reindented line", scope: "6", expected: "AssignStmt"},
+		{desc: "byte range", scope: fmt.Sprintf("%d-%d", start, start+len("x := 1")), expected: "AssignStmt"},
+		{desc: "removed line", scope: "3", err: "graph scope: line 3 is removed by the formatting"},
+		{desc: "line out of range", scope: "20", err: "graph scope: line 20 out of range: the file has 8 lines"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dotFile := filepath.Join(t.TempDir(), "graph.dot")
+
+			config := NewDefaultConfig()
+			config.MaxLen = 40
+			config.DotFile = dotFile
+			config.GraphScope = test.scope
+
+			_, err := NewShortener(config).ProcessFile("main.go", []byte(content))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			graph, err := os.ReadFile(dotFile)
+			require.NoError(t, err)
+
+			lines := strings.Split(string(graph), "\n")
+			require.Greater(t, len(lines), 1)
+
+			assert.True(t, strings.HasPrefix(lines[1], "\t"+test.expected+"_0_0["), lines[1])
+		})
+	}
+}
+
+func TestShortener_ProcessFileFrom_graph(t *testing.T) {
+	source := `package main
+
+func main() {
+	x := 1
+	fmt.Println("aaaaaaaaaaaa", "a string literal longer than the maximum")
+}
+`
+
+	// The import is added by the other formatter (e.g., goimports): the line 5 of the source is the line 7.
+	content := strings.Replace(source, "package main\n", "package main\n\nimport \"fmt\"\n", 1)
+
+	dotFile := filepath.Join(t.TempDir(), "graph.dot")
+
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+	config.DotFile = dotFile
+	config.GraphScope = "5"
+
+	_, err := NewShortener(config).ProcessFileFrom("main.go", []byte(source), []byte(content))
+	require.NoError(t, err)
+
+	graph, err := os.ReadFile(dotFile)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(graph), "\n")
+	require.Greater(t, len(lines), 1)
+
+	assert.True(t, strings.HasPrefix(lines[1], "\tExprStmt_0_0["), lines[1])
+}
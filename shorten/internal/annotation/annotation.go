@@ -58,7 +58,7 @@ func HasRecursive[T dst.Node](node T) bool {
 		return HasRecursive(n.Fun) || slices.ContainsFunc(n.Args, Has)
 
 	case *dst.InterfaceType:
-		return HasRecursive(n.Methods)
+		return n.Methods != nil && HasRecursive(n.Methods)
 
 	case *dst.FieldList:
 		return slices.ContainsFunc(n.List, HasRecursive)
@@ -130,6 +130,10 @@ func TestHasTail(t *testing.T) {
 	}
 }
 
+func TestHasRecursive_nilMethods(t *testing.T) {
+	assert.False(t, HasRecursive(&dst.InterfaceType{}))
+}
+
 func TestParse(t *testing.T) {
 	testCases := []struct {
 		desc     string
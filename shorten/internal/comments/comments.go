@@ -1,9 +1,11 @@
 package comments
 
 import (
-	"fmt"
+	"go/doc/comment"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/golangci/golines/shorten/internal"
 	"github.com/golangci/golines/shorten/internal/annotation"
@@ -16,14 +18,28 @@ import (
 type Shortener struct {
 	MaxLen int
 	TabLen int
+
+	// Rebalance Whether to rewrap whole paragraphs, including the short lines,
+	// instead of only the long lines.
+	Rebalance bool
 }
 
 // Go directive (should be ignored).
 // https://go.dev/doc/comment#syntax
-var directivePattern = regexp.MustCompile(`\s*//(line |extern |export |[a-z0-9]+:[a-z0-9])`)
+// The linter directives (e.g., `//nolint`) are also ignored.
+var directivePattern = regexp.MustCompile(`\s*//(line |extern |export |nolint\b|[a-z0-9]+:[a-z0-9])`)
+
+// Example output block (should be kept as-is because the test compares it with the actual output).
+// https://pkg.go.dev/testing#hdr-Examples
+var outputPattern = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
 
 // Process attempts to shorten long comments in the provided source.
 //
+// The comments are parsed as doc comments (https://go.dev/doc/comment):
+// only the paragraphs are rewrapped,
+// code blocks, lists, headings, link definitions, and example outputs are kept as-is.
+// This applies to the `//` comments and to the content of the `/* */` comments.
+//
 // As noted in the repo README,
 // this functionality has some quirks and is disabled by default.
 func (s *Shortener) Process(content []byte) []byte {
@@ -31,74 +47,359 @@ func (s *Shortener) Process(content []byte) []byte {
 		return content
 	}
 
+	l := scan(content)
+
+	lineComments := map[int]bool{}
+	blockComments := map[int]commentToken{}
+
+	for _, c := range l.comments {
+		switch {
+		case l.isLineComment(c):
+			lineComments[c.line] = true
+
+		case l.isBlockComment(c):
+			blockComments[c.line] = c
+		}
+	}
+
 	var cleanedLines []string
 
-	// all words in a contiguous sequence of long comments
-	var words []string
+	for i := 0; i < len(l.lines); {
+		if c, ok := blockComments[i]; ok {
+			cleanedLines = append(cleanedLines, s.reflowBlock(l.lines[c.line:c.endLine+1])...)
+			i = c.endLine + 1
 
-	prefix := ""
-
-	lines := strings.SplitSeq(string(content), "\n")
-	for line := range lines {
-		if Is(line) && !annotation.Is(line) &&
-			!isDirective(line) &&
-			internal.LineLength(line, s.TabLen) > s.MaxLen {
-			start := strings.Index(line, "//")
-			prefix = line[0:(start + 2)]
-			trimmedLine := strings.Trim(line[(start+2):], " ")
-			currLineWords := strings.Split(trimmedLine, " ")
-			words = append(words, currLineWords...)
-		} else {
-			// Reflow the accumulated `words` before appending the unprocessed `line`.
-			currLineLen := 0
-
-			var currLineWords []string
-
-			maxCommentLen := s.MaxLen - internal.LineLength(prefix, s.TabLen)
-			for _, word := range words {
-				if currLineLen > 0 && currLineLen+1+len(word) > maxCommentLen {
-					cleanedLines = append(
-						cleanedLines,
-						fmt.Sprintf(
-							"%s %s",
-							prefix,
-							strings.Join(currLineWords, " "),
-						),
-					)
-					currLineWords = []string{}
-					currLineLen = 0
-				}
-
-				currLineWords = append(currLineWords, word)
-				currLineLen += 1 + len(word)
-			}
+			continue
+		}
+
+		prefix, ok := commentPrefix(l.lines[i])
+		if !lineComments[i] || !ok {
+			cleanedLines = append(cleanedLines, l.lines[i])
+			i++
 
-			if currLineLen > 0 {
-				cleanedLines = append(
-					cleanedLines,
-					fmt.Sprintf(
-						"%s %s",
-						prefix,
-						strings.Join(currLineWords, " "),
-					),
-				)
+			continue
+		}
+
+		// Collect a contiguous sequence of comment lines with the same indentation.
+		end := i + 1
+
+		for end < len(l.lines) && lineComments[end] {
+			if p, ok := commentPrefix(l.lines[end]); !ok || p != prefix {
+				break
 			}
 
-			words = []string{}
+			end++
+		}
 
+		cleanedLines = append(cleanedLines, s.reflow(prefix, " ", l.lines[i:end], false)...)
+		i = end
+	}
+
+	return []byte(strings.Join(cleanedLines, "\n"))
+}
+
+// MoveTrailing moves the long trailing comments (e.g., `x := compute() // explanation`)
+// on their own line, above the code.
+// The directives (e.g., `//nolint`) are kept in place because they apply to their line.
+func (s *Shortener) MoveTrailing(content []byte) []byte {
+	if len(content) == 0 {
+		return content
+	}
+
+	l := scan(content)
+
+	trailingComments := map[int]commentToken{}
+
+	for _, c := range l.comments {
+		if l.isTrailingComment(c) && !IsDirective(c.text) {
+			trailingComments[c.line] = c
+		}
+	}
+
+	var cleanedLines []string
+
+	for i, line := range l.lines {
+		c, ok := trailingComments[i]
+		if !ok || internal.LineLength(line, s.TabLen) <= s.MaxLen {
 			cleanedLines = append(cleanedLines, line)
+
+			continue
 		}
+
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		cleanedLines = append(cleanedLines,
+			indent+c.text,
+			strings.TrimRight(line[:c.col], " \t"),
+		)
 	}
 
 	return []byte(strings.Join(cleanedLines, "\n"))
 }
 
+// reflowBlock rewraps the content of a `/* */` comment occupying whole lines.
+// The lines starting with `#` (e.g., C preprocessor lines) are kept as-is.
+func (s *Shortener) reflowBlock(lines []string) []string {
+	if !s.Rebalance && !slices.ContainsFunc(lines, func(line string) bool {
+		return internal.LineLength(line, s.TabLen) > s.MaxLen
+	}) {
+		return lines
+	}
+
+	if len(lines) == 1 {
+		// Single-line block comment: the continuation lines are aligned with the text.
+		line := lines[0]
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		text := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(line), "/*"), "*/")
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			return lines
+		}
+
+		wrapped := s.wrap(indent+"  ", " ", append(splitWords(text), "*/"))
+		wrapped[0] = indent + "/*" + strings.TrimPrefix(wrapped[0], indent+"  ")
+
+		return wrapped
+	}
+
+	// Only the block comments with delimiters on their own lines are handled.
+	first, last := lines[0], lines[len(lines)-1]
+	if strings.TrimSpace(first) != "/*" || strings.TrimSpace(last) != "*/" {
+		return lines
+	}
+
+	inner := lines[1 : len(lines)-1]
+
+	prefix, sep := blockPrefix(inner)
+
+	cleanedLines := []string{first}
+	cleanedLines = append(cleanedLines, s.reflow(prefix, sep, inner, true)...)
+
+	return append(cleanedLines, last)
+}
+
+// reflow rewraps the paragraphs of a sequence of comment lines sharing the same prefix.
+// The separator is the conventional whitespace between the prefix and the text.
+//
+// Inside block comments, a paragraph directly followed by an indented line is kept as-is:
+// it's most likely some commented-out code (e.g., a function signature followed by its body).
+func (s *Shortener) reflow(prefix, sep string, lines []string, block bool) []string {
+	var (
+		cleanedLines []string
+		paragraph    []string
+	)
+
+	flush := func() {
+		cleanedLines = append(cleanedLines, s.wrapParagraph(prefix, sep, paragraph)...)
+		paragraph = nil
+	}
+
+	for i, line := range lines {
+		text := commentText(prefix, sep, line)
+
+		switch {
+		case outputPattern.MatchString(text):
+			// Everything after the output marker is compared by `go test`.
+			flush()
+
+			return append(cleanedLines, lines[i:]...)
+
+		case text == "" || strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") ||
+			block && strings.HasPrefix(text, "#"):
+			// Blank lines separate the paragraphs,
+			// indented lines are code blocks or lists,
+			// and the lines starting with `#` in block comments are C preprocessor lines.
+			if block && text != "" {
+				cleanedLines = append(cleanedLines, paragraph...)
+				paragraph = nil
+			}
+
+			flush()
+
+			cleanedLines = append(cleanedLines, line)
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+
+	flush()
+
+	return cleanedLines
+}
+
+// wrapParagraph rewraps the lines of a span of unindented comment lines if it's a paragraph.
+// Unless Rebalance is enabled, only the long lines are rewrapped.
+func (s *Shortener) wrapParagraph(prefix, sep string, lines []string) []string {
+	if len(lines) == 0 || !isParagraph(prefix, sep, lines) {
+		return lines
+	}
+
+	if s.Rebalance {
+		var words []string
+
+		for _, line := range lines {
+			words = append(words, splitWords(commentText(prefix, sep, line))...)
+		}
+
+		return s.wrap(prefix, sep, words)
+	}
+
+	var (
+		cleanedLines []string
+
+		// all words in a contiguous sequence of long comments
+		words []string
+	)
+
+	for _, line := range lines {
+		if internal.LineLength(line, s.TabLen) > s.MaxLen {
+			words = append(words, splitWords(commentText(prefix, sep, line))...)
+
+			continue
+		}
+
+		cleanedLines = append(cleanedLines, s.wrap(prefix, sep, words)...)
+		cleanedLines = append(cleanedLines, line)
+
+		words = nil
+	}
+
+	return append(cleanedLines, s.wrap(prefix, sep, words)...)
+}
+
+// wrap distributes the words over as few comment lines as possible.
+func (s *Shortener) wrap(prefix, sep string, words []string) []string {
+	var (
+		cleanedLines  []string
+		currLineWords []string
+		currLineLen   int
+	)
+
+	maxCommentLen := s.MaxLen - internal.LineLength(prefix+sep, s.TabLen)
+
+	for _, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+
+		if currLineLen > 0 && currLineLen+1+wordLen > maxCommentLen {
+			cleanedLines = append(cleanedLines, prefix+sep+strings.Join(currLineWords, " "))
+			currLineWords = nil
+			currLineLen = 0
+		}
+
+		if currLineLen > 0 {
+			currLineLen++
+		}
+
+		currLineWords = append(currLineWords, word)
+		currLineLen += wordLen
+	}
+
+	if len(currLineWords) > 0 {
+		cleanedLines = append(cleanedLines, prefix+sep+strings.Join(currLineWords, " "))
+	}
+
+	return cleanedLines
+}
+
 // Is determines whether the provided line is a non-block comment.
 func Is(line string) bool {
 	return strings.HasPrefix(strings.Trim(line, " \t"), "//")
 }
 
-// isDirective determines whether the provided line is a directive, e.g., for `go:generate`.
-func isDirective(line string) bool {
+// IsDirective determines whether the provided line is a directive, e.g., for `go:generate`.
+func IsDirective(line string) bool {
 	return directivePattern.MatchString(line)
 }
+
+// isParagraph determines whether the provided comment lines are parsed as a single paragraph.
+// Headings and link definitions are not paragraphs.
+func isParagraph(prefix, sep string, lines []string) bool {
+	texts := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		texts = append(texts, commentText(prefix, sep, line))
+	}
+
+	var parser comment.Parser
+
+	doc := parser.Parse(strings.Join(texts, "\n"))
+	if len(doc.Content) != 1 || len(doc.Links) > 0 {
+		return false
+	}
+
+	_, ok := doc.Content[0].(*comment.Paragraph)
+
+	return ok
+}
+
+// commentPrefix returns the indentation and the comment marker of a comment line that can be reflowed.
+func commentPrefix(line string) (string, bool) {
+	if !Is(line) || annotation.Is(line) || IsDirective(line) {
+		return "", false
+	}
+
+	start := strings.Index(line, "//")
+
+	return line[:start+2], true
+}
+
+// blockPrefix returns the common prefix of the lines inside a block comment,
+// i.e., the indentation, followed by a star if all the lines start with one.
+func blockPrefix(lines []string) (string, string) {
+	var (
+		indent  string
+		starred = true
+		first   = true
+	)
+
+	for _, line := range lines {
+		text := strings.TrimLeft(line, " \t")
+		if text == "" {
+			continue
+		}
+
+		lineIndent := line[:len(line)-len(text)]
+
+		if first || len(lineIndent) < len(indent) {
+			indent = lineIndent
+		}
+
+		starred = starred && strings.HasPrefix(text, "*")
+		first = false
+	}
+
+	if starred && !first {
+		return indent + "*", " "
+	}
+
+	return indent, ""
+}
+
+// commentText returns the text of a comment line, without the prefix and the conventional separator.
+func commentText(prefix, sep, line string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(line, prefix), sep)
+}
+
+// splitWords splits a text into words, without breaking the doc links (e.g., `[text with spaces]`).
+func splitWords(text string) []string {
+	var words []string
+
+	inLink := false
+
+	for word := range strings.FieldsSeq(text) {
+		if inLink {
+			words[len(words)-1] += " " + word
+		} else {
+			words = append(words, word)
+		}
+
+		if open, closing := strings.LastIndex(word, "["), strings.LastIndex(word, "]"); open > closing {
+			inLink = true
+		} else if closing > open {
+			inLink = false
+		}
+	}
+
+	return words
+}
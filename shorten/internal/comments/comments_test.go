@@ -2,6 +2,7 @@ package comments
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,15 +10,83 @@ import (
 )
 
 func TestShortener_Process(t *testing.T) {
-	cs := &Shortener{MaxLen: 100, TabLen: 4}
+	testCases := []struct {
+		desc      string
+		file      string
+		shortener *Shortener
+		trailing  bool
+		expected  string
+	}{
+		{
+			desc:      "comments",
+			file:      "comments.go",
+			shortener: &Shortener{MaxLen: 100, TabLen: 4},
+			expected:  "comments.go.golden",
+		},
+		{
+			desc:      "doc comments",
+			file:      "doc_comments.go",
+			shortener: &Shortener{MaxLen: 100, TabLen: 4},
+			expected:  "doc_comments.go.golden",
+		},
+		{
+			desc:      "doc comments with rebalancing",
+			file:      "doc_comments.go",
+			shortener: &Shortener{MaxLen: 80, TabLen: 4, Rebalance: true},
+			expected:  "doc_comments_rebalance.go.golden",
+		},
+		{
+			desc:      "block comments",
+			file:      "block_comments.go",
+			shortener: &Shortener{MaxLen: 100, TabLen: 4},
+			expected:  "block_comments.go.golden",
+		},
+		{
+			desc:      "cgo preambles",
+			file:      "cgo.go",
+			shortener: &Shortener{MaxLen: 100, TabLen: 4},
+			expected:  "cgo.go.golden",
+		},
+		{
+			desc:      "trailing comments",
+			file:      "trailing_comments.go",
+			shortener: &Shortener{MaxLen: 100, TabLen: 4},
+			trailing:  true,
+			expected:  "trailing_comments.go.golden",
+		},
+	}
 
-	src, err := os.ReadFile("testdata/comments.go")
-	require.NoError(t, err)
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
 
-	result := cs.Process(src)
+			src, err := os.ReadFile(filepath.Join("testdata", test.file))
+			require.NoError(t, err)
 
-	expectedContent, err := os.ReadFile("testdata/comments.go.golden")
-	require.NoError(t, err)
+			var result []byte
+			if test.trailing {
+				result = test.shortener.MoveTrailing(src)
+			} else {
+				result = test.shortener.Process(src)
+			}
 
-	assert.Equal(t, string(expectedContent), string(result))
+			expectedPath := filepath.Join("testdata", test.expected)
+
+			if os.Getenv("REGENERATE_TEST_OUTPUTS") == "true" {
+				err = os.WriteFile(expectedPath, result, 0o644)
+				require.NoError(t, err)
+			}
+
+			expectedContent, err := os.ReadFile(expectedPath)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(expectedContent), string(result))
+		})
+	}
+}
+
+func Test_splitWords(t *testing.T) {
+	words := splitWords(" See [the documentation] and [fmt.Println]  for more.")
+
+	assert.Equal(t, []string{"See", "[the documentation]", "and", "[fmt.Println]", "for", "more."}, words)
 }
@@ -0,0 +1,148 @@
+package comments
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// commentToken is a comment found by the scanner.
+type commentToken struct {
+	text string
+
+	// line and endLine are the (0-based) indexes of the first and last lines of the comment.
+	line, endLine int
+
+	// col is the byte offset of the comment in its first line.
+	col int
+}
+
+// layout describes where the comments are in a source file, line by line.
+type layout struct {
+	lines []string
+
+	// code reports whether a line contains (a part of) a token that is not a comment.
+	code []bool
+
+	// continued reports whether a line starts inside a multi-line token,
+	// e.g., a raw string literal or a block comment.
+	continued []bool
+
+	// nbComments is the number of comments on each line.
+	nbComments []int
+
+	// preamble reports whether a line is part of a cgo preamble,
+	// i.e., the comments directly above `import "C"`.
+	preamble []bool
+
+	comments []commentToken
+}
+
+// scan builds the layout of the provided source.
+// Scanning errors are ignored: the content is expected to be valid Go code,
+// and the worst case is a comment that is not processed.
+func scan(content []byte) *layout {
+	lines := strings.Split(string(content), "\n")
+
+	l := &layout{
+		lines:      lines,
+		code:       make([]bool, len(lines)),
+		continued:  make([]bool, len(lines)),
+		nbComments: make([]int, len(lines)),
+		preamble:   make([]bool, len(lines)),
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(content))
+
+	var sc scanner.Scanner
+
+	sc.Init(file, content, nil, scanner.ScanComments)
+
+	var prev token.Token
+
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		// Skip the automatically inserted semicolons.
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		position := fset.Position(pos)
+		line := position.Line - 1
+		endLine := line + strings.Count(lit, "\n")
+
+		for i := line + 1; i <= endLine && i < len(lines); i++ {
+			l.continued[i] = true
+		}
+
+		if tok != token.COMMENT {
+			for i := line; i <= endLine && i < len(lines); i++ {
+				l.code[i] = true
+			}
+
+			if tok == token.STRING && lit == `"C"` && prev == token.IMPORT {
+				l.markPreamble(line)
+			}
+
+			prev = tok
+
+			continue
+		}
+
+		for i := line; i <= endLine && i < len(lines); i++ {
+			l.nbComments[i]++
+		}
+
+		l.comments = append(l.comments, commentToken{
+			text:    lit,
+			line:    line,
+			endLine: endLine,
+			col:     position.Column - 1,
+		})
+	}
+
+	return l
+}
+
+// markPreamble marks the comments directly above the `import "C"` on the provided line.
+// The cgo preamble is C code (e.g., `#cgo` and `#include` lines), it's never rewrapped.
+func (l *layout) markPreamble(importLine int) {
+	next := importLine - 1
+
+	for i := len(l.comments) - 1; i >= 0; i-- {
+		c := l.comments[i]
+		if c.endLine != next || l.code[c.line] || l.code[c.endLine] {
+			return
+		}
+
+		for j := c.line; j <= c.endLine; j++ {
+			l.preamble[j] = true
+		}
+
+		next = c.line - 1
+	}
+}
+
+// isLineComment determines whether a `//` comment is alone on its line.
+func (l *layout) isLineComment(c commentToken) bool {
+	return strings.HasPrefix(c.text, "//") && !l.code[c.line] && l.nbComments[c.line] == 1 &&
+		!l.preamble[c.line]
+}
+
+// isTrailingComment determines whether a `//` comment follows some code on its line.
+func (l *layout) isTrailingComment(c commentToken) bool {
+	return strings.HasPrefix(c.text, "//") && l.code[c.line] && !l.continued[c.line]
+}
+
+// isBlockComment determines whether a `/* */` comment occupies whole lines.
+func (l *layout) isBlockComment(c commentToken) bool {
+	return strings.HasPrefix(c.text, "/*") &&
+		!l.code[c.line] && !l.code[c.endLine] &&
+		l.nbComments[c.line] == 1 && l.nbComments[c.endLine] == 1 &&
+		!l.preamble[c.line]
+}
@@ -0,0 +1,18 @@
+package testdata
+
+/*
+ * A starred block comment. Really long lines in here are rewrapped, and the stars are kept at the beginning of lines.
+ *
+ *     code := "kept as-is, because it's indented, even if it's longer than the maximum line length......"
+ */
+
+/* A single-line block comment, longer than the maximum line length, is wrapped inside its delimiters. */
+
+/*
+A block comment without indentation. Really long lines in here are rewrapped as well, like the other ones.
+*/
+func _() {
+	x := compute( /* inline block comments are never rewrapped, even if they're longer than the limit */ )
+
+	_ = x
+}
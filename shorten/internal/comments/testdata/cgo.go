@@ -0,0 +1,19 @@
+package testdata
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/lib -lfoo -lbar -lbaz -lqux -lquux -lcorge -lgrault -lgarply -lwaldo -lfred
+#define GREETING "a C string literal that is longer than the maximum line length, and must stay on one line"
+#include <stdlib.h>
+*/
+import "C"
+
+// #cgo CFLAGS: -I${SRCDIR}/include -DFOO=1 -DBAR=2 -DBAZ=3 -DQUX=4 -DQUUX=5 -DCORGE=6 -DGRAULT=7 -DGARPLY=8
+// #include "foo.h"
+import "C"
+
+/*
+A block comment that is not a cgo preamble. Really long lines in here are rewrapped, like the other ones.
+
+#define MACRO_KEPT_AS_IS "the lines starting with a hash are C preprocessor lines, and they're never rewrapped"
+*/
+func _() {}
@@ -0,0 +1,31 @@
+package testdata
+
+import "fmt"
+
+// Package-style doc comment with a long paragraph that should be rewrapped because it's longer than the maximum.
+//
+// # A heading that is long enough to be over the maximum line length but must never be rewrapped at all
+//
+// A list:
+//   - first item of the list, which is long enough to be over the maximum line length, it's kept as-is
+//   - second item
+//
+// A code block:
+//
+//	func(aReallyLongArgument string, anotherReallyLongArgument string) (string, error) { return "", nil }
+//
+// See [the documentation] and [fmt.Println] for more details, this paragraph is also long enough to be rewrapped.
+//
+// [the documentation]: https://pkg.go.dev/github.com/golangci/golines/shorten/internal/comments#Shortener
+func _() {}
+
+func ExampleShortener() {
+	fmt.Println("This is the output of an example test that is longer than the maximum line length")
+	// Output:
+	// This is the output of an example test that is longer than the maximum line length, and must be kept
+}
+
+// A paragraph with short lines
+// that are not rewrapped
+// unless the rebalancing is enabled.
+func _() {}
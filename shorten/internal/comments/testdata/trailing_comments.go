@@ -0,0 +1,14 @@
+package testdata
+
+func _() {
+	x := compute() // This trailing comment is long enough to push the line over the maximum line length.
+	y := compute() // This one is short.
+
+	result, err := client.Do(ctx, req) //nolint:errcheck // This directive is long but must stay on its line.
+
+	//lint:ignore SA1019 the directive on its own line is not a trailing comment, and is never moved.
+	z := compute() //lint:ignore SA1019 this directive is long, but it must stay on the line it applies to.
+
+	s := `a raw string
+literal` // This trailing comment is long, but it can't be moved above the line because of the string.
+}
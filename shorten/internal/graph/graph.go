@@ -34,14 +34,51 @@ type Edge struct {
 
 // CreateDot creates a dot representation of the graph associated with a dst node.
 func CreateDot(node dst.Node, out io.Writer) error {
+	return Create(node, FormatDot, out)
+}
+
+// Formats of the graph.
+const (
+	// FormatDot is the Graphviz (dot) format.
+	FormatDot = "dot"
+
+	// FormatJSON is a JSON tree of the nodes.
+	FormatJSON = "json"
+
+	// FormatMermaid is a Mermaid flowchart.
+	FormatMermaid = "mermaid"
+
+	// FormatHTML is a self-contained HTML page with a collapsible tree of the nodes.
+	FormatHTML = "html"
+)
+
+// Create creates a representation of the graph associated with a dst node, in the provided format.
+func Create(node dst.Node, format string, out io.Writer) error {
 	root := NodeToGraphNode(node)
 
-	dotGraph, err := Walk(root)
+	var (
+		content string
+		err     error
+	)
+
+	switch format {
+	case FormatDot, "":
+		content, err = Walk(root)
+	case FormatJSON:
+		content, err = WalkJSON(root)
+	case FormatMermaid:
+		content, err = WalkMermaid(root)
+	case FormatHTML:
+		content, err = WalkHTML(root)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+
 	if err != nil {
 		return err
 	}
 
-	_, err = out.Write([]byte(dotGraph))
+	_, err = out.Write([]byte(content))
 
 	return err
 }
@@ -49,41 +86,10 @@ func CreateDot(node dst.Node, out io.Writer) error {
 // Walk walks the graph starting at the argument root and returns
 // a graphviz (dot) representation.
 func Walk(root *Node) (string, error) {
-	toProcess := []*Node{root}
-
-	var processed []*Node
-
 	outLines := []string{"digraph {"}
 
-	var (
-		currLevel int
-		currSeq   int
-	)
-
-	// First, loop through the graph nodes to assign proper ids
-
-	for len(toProcess) != 0 {
-		currNode := toProcess[0]
-
-		if currNode.level > currLevel {
-			currLevel = currNode.level
-			currSeq = 0
-		}
-
-		currNode.seq = currSeq
-		currSeq++
-
-		processed = append(processed, currNode)
-		toProcess = toProcess[1:]
-
-		for _, edge := range currNode.Edges {
-			edge.Dest.level = currLevel + 1
-			toProcess = append(toProcess, edge.Dest)
-		}
-	}
-
-	// Then, fill out the graph in dot format
-	for _, node := range processed {
+	// Fill out the graph in dot format
+	for _, node := range assignIDs(root) {
 		var (
 			nodeLabel  string
 			nodeFormat string
@@ -130,3 +136,38 @@ func Walk(root *Node) (string, error) {
 
 	return strings.Join(outLines, "\n"), nil
 }
+
+// assignIDs loops through the graph nodes, breadth first, to assign their ids.
+// It returns the nodes in this order.
+func assignIDs(root *Node) []*Node {
+	toProcess := []*Node{root}
+
+	var processed []*Node
+
+	var (
+		currLevel int
+		currSeq   int
+	)
+
+	for len(toProcess) != 0 {
+		currNode := toProcess[0]
+
+		if currNode.level > currLevel {
+			currLevel = currNode.level
+			currSeq = 0
+		}
+
+		currNode.seq = currSeq
+		currSeq++
+
+		processed = append(processed, currNode)
+		toProcess = toProcess[1:]
+
+		for _, edge := range currNode.Edges {
+			edge.Dest.level = currLevel + 1
+			toProcess = append(toProcess, edge.Dest)
+		}
+	}
+
+	return processed
+}
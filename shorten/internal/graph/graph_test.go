@@ -27,3 +27,45 @@ func TestCreateDot(t *testing.T) {
 
 	assert.Equal(t, string(bytes.TrimSpace(expected)), out.String())
 }
+
+func TestCreate(t *testing.T) {
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{format: FormatDot, expected: "testdata/sample02.dot"},
+		{format: FormatJSON, expected: "testdata/sample02.json"},
+		{format: FormatMermaid, expected: "testdata/sample02.mmd"},
+		{format: FormatHTML, expected: "testdata/sample02.html"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.format, func(t *testing.T) {
+			t.Parallel()
+
+			file, err := os.ReadFile("testdata/sample02.go")
+			require.NoError(t, err)
+
+			node, err := decorator.Parse(file)
+			require.NoError(t, err)
+
+			out := &bytes.Buffer{}
+
+			err = Create(node, test.format, out)
+			require.NoError(t, err)
+
+			expected, err := os.ReadFile(test.expected)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(bytes.TrimSpace(expected)), out.String())
+		})
+	}
+}
+
+func TestCreate_unknownFormat(t *testing.T) {
+	node, err := decorator.Parse("package mypackage\n")
+	require.NoError(t, err)
+
+	err = Create(node, "svg", &bytes.Buffer{})
+	require.EqualError(t, err, `unknown graph format "svg"`)
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/golangci/golines/shorten/internal/annotation"
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>AST graph</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+details, .leaf { margin-left: 1.5em; }
+summary { cursor: pointer; }
+.rel { color: #777777; }
+.value { font-family: monospace; color: #777777; }
+.annotated > summary .type, .leaf.annotated .type { font-weight: bold; border: 2px solid; padding: 0 2px; }
+</style>
+</head>
+<body>
+<button onclick="toggle(true)">Expand all</button>
+<button onclick="toggle(false)">Collapse all</button>
+<script>
+function toggle(open) {
+	document.querySelectorAll("details").forEach(function (d) { d.open = open; });
+}
+</script>`
+
+const htmlFooter = `</body>
+</html>`
+
+// WalkHTML walks the graph starting at the argument root and returns
+// a self-contained HTML page, with a collapsible tree of the nodes where the annotated nodes are highlighted.
+func WalkHTML(root *Node) (string, error) {
+	outLines := []string{htmlHeader}
+
+	outLines = appendHTMLNode(outLines, root, "", 0)
+
+	outLines = append(outLines, htmlFooter)
+
+	return strings.Join(outLines, "\n"), nil
+}
+
+func appendHTMLNode(outLines []string, node *Node, relationship string, depth int) []string {
+	indent := strings.Repeat("\t", depth)
+
+	var class string
+	if annotation.Has(node.Node) {
+		class = " annotated"
+	}
+
+	label := fmt.Sprintf(`<span class="type">%s</span>`, node.Type)
+
+	if relationship != "" {
+		label = fmt.Sprintf(`<span class="rel">%s:</span> %s`, relationship, label)
+	}
+
+	if node.Value != "" {
+		label += fmt.Sprintf(` <span class="value">%s</span>`, html.EscapeString(node.Value))
+	}
+
+	if len(node.Edges) == 0 {
+		return append(outLines, fmt.Sprintf(`%s<div class="leaf%s">%s</div>`, indent, class, label))
+	}
+
+	outLines = append(outLines,
+		fmt.Sprintf(`%s<details class="node%s" open>`, indent, class),
+		fmt.Sprintf(`%s<summary>%s</summary>`, indent, label),
+	)
+
+	for _, edge := range node.Edges {
+		outLines = appendHTMLNode(outLines, edge.Dest, edge.Relationship, depth+1)
+	}
+
+	return append(outLines, indent+"</details>")
+}
@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"encoding/json"
+
+	"github.com/golangci/golines/shorten/internal/annotation"
+)
+
+// jsonNode is the JSON representation of a node.
+type jsonNode struct {
+	ID           string      `json:"id"`
+	Type         string      `json:"type"`
+	Value        string      `json:"value,omitempty"`
+	Relationship string      `json:"relationship,omitempty"`
+	Annotated    bool        `json:"annotated,omitempty"`
+	Children     []*jsonNode `json:"children,omitempty"`
+}
+
+// WalkJSON walks the graph starting at the argument root and returns
+// a JSON representation: a tree of nodes, with the relationship to their parent,
+// and whether they have a line length annotation.
+func WalkJSON(root *Node) (string, error) {
+	assignIDs(root)
+
+	content, err := json.MarshalIndent(toJSONNode(root, ""), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+func toJSONNode(node *Node, relationship string) *jsonNode {
+	n := &jsonNode{
+		ID:           node.id(),
+		Type:         node.Type,
+		Value:        node.Value,
+		Relationship: relationship,
+		Annotated:    annotation.Has(node.Node),
+	}
+
+	for _, edge := range node.Edges {
+		n.Children = append(n.Children, toJSONNode(edge.Dest, edge.Relationship))
+	}
+
+	return n
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golangci/golines/shorten/internal/annotation"
+)
+
+// mermaidEscaper escapes the characters with a special meaning in the Mermaid labels.
+var mermaidEscaper = strings.NewReplacer(
+	`"`, "#quot;",
+	"#", "#35;",
+	"&", "#amp;",
+	"<", "#lt;",
+	">", "#gt;",
+)
+
+// WalkMermaid walks the graph starting at the argument root and returns
+// a Mermaid flowchart representation, where the annotated nodes have a thick border.
+func WalkMermaid(root *Node) (string, error) {
+	outLines := []string{"flowchart TD", "\tclassDef annotated stroke-width:3px"}
+
+	for _, node := range assignIDs(root) {
+		nodeLabel := node.Type
+		if node.Value != "" {
+			nodeLabel += "<br/><code>" + mermaidEscaper.Replace(node.Value) + "</code>"
+		}
+
+		var nodeClass string
+		if annotation.Has(node.Node) {
+			nodeClass = ":::annotated"
+		}
+
+		outLines = append(outLines, fmt.Sprintf("\t%s[\"%s\"]%s", node.id(), nodeLabel, nodeClass))
+
+		for _, edge := range node.Edges {
+			outLines = append(
+				outLines,
+				fmt.Sprintf("\t%s -->|%s| %s", node.id(), edge.Relationship, edge.Dest.id()),
+			)
+		}
+	}
+
+	return strings.Join(outLines, "\n"), nil
+}
@@ -0,0 +1,6 @@
+package mypackage
+
+func myfunc() {
+	//golines:shorten:120
+	fmt.Println("a <b> & \"c\" #d")
+}
@@ -1,41 +1,79 @@
 package tags
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"go/token"
 	"regexp"
 	"slices"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
 	"github.com/golangci/golines/shorten/internal/annotation"
 	"github.com/ldez/structtags/parser"
 )
 
 var structTagRegexp = regexp.MustCompile("`([ ]*[a-zA-Z0-9_-]+:\".*\"[ ]*){2,}`")
 
+// Alignment is the way the struct tags are formatted.
+type Alignment string
+
+// Alignments of the struct tags.
+const (
+	// AlignNone keeps the struct tags as-is.
+	AlignNone Alignment = "none"
+
+	// AlignBlocks aligns the keys of the struct tags within each block of fields.
+	AlignBlocks Alignment = "blocks"
+
+	// AlignSpacing only normalizes the spacing between the keys of each struct tag.
+	AlignSpacing Alignment = "spacing"
+)
+
 // HasMultipleEntries returns whether the given lines have a multi-entries struct line.
 // It's used as an optimization step to avoid unnecessary shortening rounds.
 func HasMultipleEntries(lines []string) bool {
 	return slices.ContainsFunc(lines, structTagRegexp.MatchString)
 }
 
-// FormatStructTags formats struct tags so that the keys within each block of fields are aligned.
+// FormatStructTags formats struct tags according to the alignment:
+// by default, the keys within each block of fields are aligned.
+// If an order is provided, the keys are sorted in this canonical order,
+// and the keys not listed are sorted alphabetically after them, whatever the alignment.
 // It's not technically a shortening (and it usually makes these tags longer), so it's being
 // kept separate from the core shortening logic for now.
 //
 // See the struct_tags fixture for examples.
-func FormatStructTags(fieldList *dst.FieldList) {
+func FormatStructTags(fieldList *dst.FieldList, alignment Alignment, order []string) {
 	if fieldList == nil || len(fieldList.List) == 0 {
 		return
 	}
 
+	switch alignment {
+	case AlignNone:
+		for _, field := range fieldList.List {
+			reorder(field, order)
+		}
+
+		return
+
+	case AlignSpacing:
+		for _, field := range fieldList.List {
+			normalize(field, order)
+		}
+
+		return
+	}
+
 	var blockFields []*dst.Field
 
 	// Divide fields into "blocks" so that we don't do alignments across blank lines and comments.
 	for _, field := range fieldList.List {
 		if isEndFieldsBlock(field) {
-			align(blockFields)
+			align(blockFields, order)
 
 			blockFields = blockFields[:0]
 		}
@@ -43,7 +81,7 @@ func FormatStructTags(fieldList *dst.FieldList) {
 		blockFields = append(blockFields, field)
 	}
 
-	align(blockFields)
+	align(blockFields, order)
 }
 
 func isEndFieldsBlock(field *dst.Field) bool {
@@ -56,11 +94,8 @@ func isEndFieldsBlock(field *dst.Field) bool {
 // align formats the struct tags within a single field block.
 // NOTE(ldez): all the code of `align` is not related to shorten,
 // instead of shortening a line, it increases the line length.
-// It must be either:
-// - disabled by default
-// - or the additional spaces should be an option
-// - or maybe removed.
-func align(fields []*dst.Field) {
+// The additional spaces can be avoided with the AlignSpacing or AlignNone alignments.
+func align(fields []*dst.Field, order []string) {
 	if len(fields) == 0 {
 		return
 	}
@@ -89,22 +124,15 @@ func align(fields []*dst.Field) {
 			}
 		}
 
-		if field.Tag == nil {
-			continue
-		}
-
-		tagValue := field.Tag.Value
-
-		// The dst library doesn't strip off the backticks, so we need to do this manually
-		if tagValue[0] != '`' || tagValue[len(tagValue)-1] != '`' {
+		tagValue, ok := unquote(field.Tag)
+		if !ok {
 			continue
 		}
 
-		tagValue = tagValue[1 : len(tagValue)-1]
-
 		entries, err := parser.Tag(tagValue, newFiller())
 		if err != nil {
-			return
+			// A malformed tag is kept as-is (see Validate), the other tags of the block are still aligned.
+			continue
 		}
 
 		for _, entry := range entries {
@@ -126,6 +154,8 @@ func align(fields []*dst.Field) {
 		}
 	}
 
+	sortKeys(tagKeys, order, func(key string) string { return key })
+
 	// Go over all the fields again, replacing each tag with a reformatted one
 	for f, field := range fields {
 		if tagKVs[f] == nil {
@@ -164,59 +194,161 @@ func align(fields []*dst.Field) {
 	}
 }
 
-// getWidth tries to guess the formatted width of a dst node expression.
-// If this isn't (yet) possible, it returns an error.
-func getWidth(node dst.Node) (int, error) {
-	switch n := node.(type) {
-	case *dst.ArrayType:
-		eltWidth, err := getWidth(n.Elt)
-		if err != nil {
-			return 0, err
-		}
+// normalize formats the struct tag of a single field with a single space between the keys.
+func normalize(field *dst.Field, order []string) {
+	tagValue, ok := unquote(field.Tag)
+	if !ok {
+		return
+	}
 
-		return 2 + eltWidth, nil
+	entries, err := parser.Tag(tagValue, newFiller())
+	if err != nil {
+		return
+	}
 
-	case *dst.ChanType:
-		valWidth, err := getWidth(n.Value)
-		if err != nil {
-			return 0, err
-		}
+	sortKeys(entries, order, func(entry *tagEntry) string { return entry.Key })
 
-		isSend := n.Dir&dst.SEND > 0
-		isRecv := n.Dir&dst.RECV > 0
+	tagComponents := make([]string, 0, len(entries))
 
-		if isSend && isRecv {
-			// Channel does not include an arrow
-			return 5 + valWidth, nil
-		}
+	for _, entry := range entries {
+		tagComponents = append(tagComponents, entry.Content)
+	}
 
-		// Channel includes an arrow
-		return 7 + valWidth, nil
+	field.Tag.Value = fmt.Sprintf("`%s`", strings.Join(tagComponents, " "))
+}
 
-	case *dst.Ident:
-		return len(n.Name), nil
+// reorder sorts the keys of a struct tag in the canonical order, without aligning them.
+// The tags already in this order are kept as-is, the spacing of the other ones is normalized.
+func reorder(field *dst.Field, order []string) {
+	if len(order) == 0 {
+		return
+	}
 
-	case *dst.MapType:
-		keyWidth, err := getWidth(n.Key)
-		if err != nil {
-			return 0, err
-		}
+	tagValue, ok := unquote(field.Tag)
+	if !ok {
+		return
+	}
 
-		valWidth, err := getWidth(n.Value)
-		if err != nil {
-			return 0, err
-		}
+	entries, err := parser.Tag(tagValue, newFiller())
+	if err != nil {
+		return
+	}
 
-		return 5 + keyWidth + valWidth, nil
+	sorted := slices.Clone(entries)
+	sortKeys(sorted, order, func(entry *tagEntry) string { return entry.Key })
 
-	case *dst.StarExpr:
-		xWidth, err := getWidth(n.X)
-		if err != nil {
-			return 0, err
+	if slices.Equal(entries, sorted) {
+		return
+	}
+
+	normalize(field, order)
+}
+
+// Canonical returns a canonical form of a struct tag literal, independent of the formatting:
+// the entries sorted by key, separated by a single space.
+// The literals that are not raw strings, and the malformed tags, are returned as-is.
+func Canonical(literal string) string {
+	tagValue, ok := unquote(&dst.BasicLit{Value: literal})
+	if !ok {
+		return literal
+	}
+
+	entries, err := parser.Tag(tagValue, newFiller())
+	if err != nil {
+		return literal
+	}
+
+	slices.SortStableFunc(entries, func(a, b *tagEntry) int { return strings.Compare(a.Key, b.Key) })
+
+	tagComponents := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		tagComponents = append(tagComponents, entry.Content)
+	}
+
+	return strings.Join(tagComponents, " ")
+}
+
+// sortKeys sorts the elements by key in the canonical order:
+// the keys of the order first, then the other keys alphabetically.
+// The elements are kept in their order of appearance if the order is empty.
+func sortKeys[T any](elts []T, order []string, key func(T) string) {
+	if len(order) == 0 {
+		return
+	}
+
+	rank := func(k string) int {
+		if i := slices.Index(order, k); i >= 0 {
+			return i
 		}
 
-		return 1 + xWidth, nil
+		return len(order)
+	}
+
+	slices.SortStableFunc(elts, func(a, b T) int {
+		ka, kb := key(a), key(b)
+
+		return cmp.Or(cmp.Compare(rank(ka), rank(kb)), strings.Compare(ka, kb))
+	})
+}
+
+// unquote returns the raw value of a struct tag.
+func unquote(tag *dst.BasicLit) (string, bool) {
+	if tag == nil {
+		return "", false
+	}
+
+	tagValue := tag.Value
+
+	// The dst library doesn't strip off the backticks, so we need to do this manually
+	if len(tagValue) < 2 || tagValue[0] != '`' || tagValue[len(tagValue)-1] != '`' {
+		return "", false
+	}
+
+	return tagValue[1 : len(tagValue)-1], true
+}
+
+// getWidth returns the formatted width of a dst node expression, measured on its printed form.
+// If the expression is printed on several lines (e.g., a struct type), it returns an error.
+func getWidth(node dst.Expr) (int, error) {
+	expr, ok := dst.Clone(node).(dst.Expr)
+	if !ok {
+		return 0, fmt.Errorf("could not clone the node %+v", node)
+	}
+
+	// The spacing around the node is not part of its width.
+	expr.Decorations().Before = dst.None
+	expr.Decorations().After = dst.None
+	expr.Decorations().Start.Clear()
+	expr.Decorations().End.Clear()
+
+	const prefix = "type T "
+
+	// The printer only works on files, so the expression is wrapped in a type declaration.
+	file := &dst.File{
+		Name: dst.NewIdent("p"),
+		Decls: []dst.Decl{&dst.GenDecl{
+			Tok:   token.TYPE,
+			Specs: []dst.Spec{&dst.TypeSpec{Name: dst.NewIdent("T"), Type: expr}},
+		}},
+	}
+
+	var buf bytes.Buffer
+
+	err := decorator.Fprint(&buf, file)
+	if err != nil {
+		return 0, fmt.Errorf("could not print the node %+v: %w", node, err)
+	}
+
+	_, printed, ok := strings.Cut(buf.String(), prefix)
+	if !ok {
+		return 0, fmt.Errorf("could not get the width of node %+v", node)
+	}
+
+	printed = strings.TrimSuffix(printed, "\n")
+	if strings.Contains(printed, "\n") {
+		return 0, fmt.Errorf("the node %+v is printed on several lines", node)
 	}
 
-	return 0, fmt.Errorf("could not get the width of node %+v", node)
+	return utf8.RuneCountInString(printed), nil
 }
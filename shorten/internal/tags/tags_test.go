@@ -5,6 +5,7 @@ import (
 
 	"github.com/dave/dst"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHasMultipleEntries(t *testing.T) {
@@ -266,7 +267,7 @@ func TestFormatStructTags(t *testing.T) {
 				List: test.list,
 			}
 
-			FormatStructTags(fl)
+			FormatStructTags(fl, AlignBlocks, nil)
 
 			var actual []string
 
@@ -282,3 +283,227 @@ func TestFormatStructTags(t *testing.T) {
 		})
 	}
 }
+
+func Test_getWidth(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		expr     dst.Expr
+		expected int
+	}{
+		{
+			desc:     "ident",
+			expr:     &dst.Ident{Name: "string"},
+			expected: 6,
+		},
+		{
+			desc:     "selector",
+			expr:     &dst.SelectorExpr{X: &dst.Ident{Name: "time"}, Sel: &dst.Ident{Name: "Duration"}},
+			expected: 13,
+		},
+		{
+			desc: "generic",
+			expr: &dst.IndexExpr{
+				X:     &dst.SelectorExpr{X: &dst.Ident{Name: "atomic"}, Sel: &dst.Ident{Name: "Pointer"}},
+				Index: &dst.Ident{Name: "T"},
+			},
+			expected: 17,
+		},
+		{
+			desc: "map",
+			expr: &dst.MapType{
+				Key:   &dst.Ident{Name: "string"},
+				Value: &dst.StarExpr{X: &dst.Ident{Name: "int"}},
+			},
+			expected: 15,
+		},
+		{
+			desc:     "empty struct",
+			expr:     &dst.StructType{Fields: &dst.FieldList{Opening: true, Closing: true}},
+			expected: 8,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			width, err := getWidth(test.expr)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, width)
+		})
+	}
+}
+
+func Test_getWidth_multiline(t *testing.T) {
+	expr := &dst.StructType{Fields: &dst.FieldList{List: []*dst.Field{
+		{
+			Names: []*dst.Ident{{Name: "A"}},
+			Type:  &dst.Ident{Name: "string"},
+			Decs:  dst.FieldDecorations{NodeDecs: dst.NodeDecs{Before: dst.NewLine, After: dst.NewLine}},
+		},
+	}}}
+
+	_, err := getWidth(expr)
+	require.Error(t, err)
+}
+
+func TestFormatStructTags_spacing(t *testing.T) {
+	fl := &dst.FieldList{
+		List: []*dst.Field{
+			{
+				Names: []*dst.Ident{{Name: "key"}},
+				Type:  &dst.Ident{Name: "string"},
+				Tag: &dst.BasicLit{
+					Value: "`  tagKey2:\"tag value2\"   tagKey1:\"tag value1\" `",
+				},
+			},
+		},
+	}
+
+	FormatStructTags(fl, AlignSpacing, nil)
+
+	assert.Equal(t, "`tagKey2:\"tag value2\" tagKey1:\"tag value1\"`", fl.List[0].Tag.Value)
+
+	FormatStructTags(fl, AlignNone, nil)
+
+	assert.Equal(t, "`tagKey2:\"tag value2\" tagKey1:\"tag value1\"`", fl.List[0].Tag.Value)
+}
+
+func TestFormatStructTags_order(t *testing.T) {
+	newFieldList := func() *dst.FieldList {
+		return &dst.FieldList{
+			List: []*dst.Field{
+				{
+					Names: []*dst.Ident{{Name: "a"}},
+					Type:  &dst.Ident{Name: "string"},
+					Tag:   &dst.BasicLit{Value: "`validate:\"required\" xml:\"a\" json:\"a\" db:\"a\"`"},
+				},
+				{
+					Names: []*dst.Ident{{Name: "bb"}},
+					Type:  &dst.Ident{Name: "int"},
+					Tag:   &dst.BasicLit{Value: "`db:\"bb\" bson:\"bb\" json:\"bb\"`"},
+				},
+			},
+		}
+	}
+
+	order := []string{"json", "yaml", "db", "validate"}
+
+	fl := newFieldList()
+
+	FormatStructTags(fl, AlignBlocks, order)
+
+	assert.Equal(t, "`json:\"a\"  db:\"a\"  validate:\"required\"           xml:\"a\"`", fl.List[0].Tag.Value)
+	assert.Equal(t, "`json:\"bb\" db:\"bb\"                     bson:\"bb\"`", fl.List[1].Tag.Value)
+
+	fl = newFieldList()
+
+	FormatStructTags(fl, AlignSpacing, order)
+
+	assert.Equal(t, "`json:\"a\" db:\"a\" validate:\"required\" xml:\"a\"`", fl.List[0].Tag.Value)
+	assert.Equal(t, "`json:\"bb\" db:\"bb\" bson:\"bb\"`", fl.List[1].Tag.Value)
+}
+
+func TestFormatStructTags_malformed(t *testing.T) {
+	fl := &dst.FieldList{
+		List: []*dst.Field{
+			{
+				Names: []*dst.Ident{{Name: "a"}},
+				Type:  &dst.Ident{Name: "string"},
+				Tag:   &dst.BasicLit{Value: "`json:\"a\"  xml:a`"},
+			},
+			{
+				Names: []*dst.Ident{{Name: "b"}},
+				Type:  &dst.Ident{Name: "string"},
+				Tag:   &dst.BasicLit{Value: "`json:\"b\"  xml:\"b\"`"},
+			},
+		},
+	}
+
+	FormatStructTags(fl, AlignBlocks, nil)
+
+	assert.Equal(t, "`json:\"a\"  xml:a`", fl.List[0].Tag.Value)
+	assert.Equal(t, "`json:\"b\" xml:\"b\"`", fl.List[1].Tag.Value)
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		tag      string
+		expected []string
+	}{
+		{
+			desc: "valid",
+			tag:  `json:"a" yaml:"a,omitempty"`,
+		},
+		{
+			desc:     "duplicate key",
+			tag:      `json:"a" xml:"a" json:"b"`,
+			expected: []string{`duplicate struct tag key "json"`},
+		},
+		{
+			desc:     "unquoted value",
+			tag:      `json:a`,
+			expected: []string{"invalid struct tag value `json:a`: missing opening quote"},
+		},
+		{
+			desc:     "missing colon",
+			tag:      `json "a"`,
+			expected: []string{"invalid struct tag syntax `json \"a\"`: missing `:`"},
+		},
+		{
+			desc:     "missing closing quote",
+			tag:      `json:"a`,
+			expected: []string{"invalid struct tag value `json:\"a`: missing closing quote"},
+		},
+		{
+			desc: "duplicate key before a syntax error",
+			tag:  `json:"a" json:"b" xml`,
+			expected: []string{
+				`duplicate struct tag key "json"`,
+				"invalid struct tag syntax `json:\"a\" json:\"b\" xml`: missing `:`",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, Validate(test.tag))
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		literal  string
+		expected string
+	}{
+		{
+			desc:     "aligned",
+			literal:  "`yaml:\"a\"   json:\"a,omitempty\"`",
+			expected: `json:"a,omitempty" yaml:"a"`,
+		},
+		{
+			desc:     "interpreted string",
+			literal:  `"json:\"a\""`,
+			expected: `"json:\"a\""`,
+		},
+		{
+			desc:     "malformed",
+			literal:  "`json:a`",
+			expected: "`json:a`",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, Canonical(test.literal))
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package tags
+
+import (
+	"fmt"
+
+	"github.com/ldez/structtags/parser"
+)
+
+// Validate returns the problems of a struct tag (without the backticks):
+// malformed syntax, unquoted values, and duplicate keys.
+// The syntax is the one of [reflect.StructTag], the parsing stops at the first syntax error.
+func Validate(tag string) []string {
+	c := &checker{seen: map[string]bool{}}
+
+	_, err := parser.Tag(tag, c)
+	if err != nil {
+		c.problems = append(c.problems, err.Error())
+	}
+
+	return c.problems
+}
+
+// checker is a filler that collects the problems of a struct tag.
+type checker struct {
+	seen map[string]bool
+
+	problems []string
+}
+
+func (c *checker) Data() []string {
+	return c.problems
+}
+
+func (c *checker) Fill(key, _ string) error {
+	if c.seen[key] {
+		c.problems = append(c.problems, fmt.Sprintf("duplicate struct tag key %q", key))
+	}
+
+	c.seen[key] = true
+
+	return nil
+}
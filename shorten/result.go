@@ -0,0 +1,31 @@
+package shorten
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// Result is the result of the shortening of a file.
+type Result struct {
+	// Content The shortened content
+	Content []byte
+
+	// Warnings The problems found in the file that don't prevent the shortening
+	Warnings []Warning
+
+	// Stats The counters of the shortening
+	Stats Stats
+
+	// Trace The decisions of the shortener, with the Trace option
+	Trace []TraceEvent
+}
+
+// Warning is a problem found in a file that doesn't prevent the shortening, e.g., a malformed struct tag.
+type Warning struct {
+	Pos     token.Position
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Pos, w.Message)
+}
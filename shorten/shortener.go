@@ -2,23 +2,25 @@ package shorten
 
 import (
 	"bytes"
-	"fmt"
+	"cmp"
+	"crypto/sha256"
+	"errors"
 	"go/format"
+	"go/token"
 	"log/slog"
-	"os"
+	"regexp"
 	"strings"
 
-	"github.com/dave/dst"
 	"github.com/dave/dst/decorator"
 	"github.com/golangci/golines/shorten/internal/comments"
 	"github.com/golangci/golines/shorten/internal/graph"
 	"github.com/golangci/golines/shorten/internal/tags"
 )
 
-// The maximum number of shortening "rounds" that we'll allow.
+// DefaultMaxRounds is the default maximum number of shortening "rounds" that we'll allow.
 // The shortening process should converge quickly,
 // but we have this here as a safety mechanism to prevent loops that prevent termination.
-const maxRounds = 20
+const DefaultMaxRounds = 20
 
 // Config stores the configuration options exposed by a Shortener instance.
 type Config struct {
@@ -28,30 +30,111 @@ type Config struct {
 	// TabLen Width of a tab character
 	TabLen int
 
+	// MaxRounds Maximum number of shortening rounds (DefaultMaxRounds is used if zero)
+	MaxRounds int
+
+	// Verify Whether to check that the output has the same syntax tree and comments as the input,
+	// and to report an error with a minimized reproducer otherwise
+	Verify bool
+
+	// Tolerant Whether to shorten the top-level declarations that parse in a file with syntax errors,
+	// keeping the broken ones unchanged, and to report the syntax errors as warnings
+	Tolerant bool
+
+	// CheckIdempotency Whether to process the output a second time and to report the differences,
+	// and to report the rounds that don't converge (round limit hit, or cycle), as errors
+	CheckIdempotency bool
+
 	// KeepAnnotations Whether to keep annotations in the final result (for debugging only)
 	KeepAnnotations bool
 
 	// ShortenComments Whether to shorten comments
 	ShortenComments bool
 
+	// CommentMaxLen Max target width for each comment line (MaxLen is used if zero)
+	CommentMaxLen int
+
+	// RebalanceComments Whether to rewrap whole comment paragraphs, including the short lines
+	RebalanceComments bool
+
+	// MoveTrailingComments Whether to move long trailing comments on their own line, above the code
+	MoveTrailingComments bool
+
 	// ReformatTags Whether to reformat struct tags in addition to shortening long lines
 	ReformatTags bool
 
+	// TagAlignment How to reformat struct tags: none, blocks, or spacing (blocks is used if empty)
+	TagAlignment string
+
+	// TagOrder Canonical order of the struct tag keys, the other keys are sorted alphabetically after them
+	// (the keys are kept in their order of appearance if empty)
+	TagOrder []string
+
+	// ValidateTags Whether to report the malformed struct tags, the duplicate keys, and the unquoted values
+	ValidateTags bool
+
 	// DotFile Path to write dot-formatted output to (for debugging only)
 	DotFile string
 
+	// GraphFormat Format of the graph written to DotFile: dot, json, mermaid, or html (defaults to dot)
+	GraphFormat string
+
+	// GraphScope Part of the file rendered in the graph: a line (e.g., `123`), a function name
+	// (e.g., `myFunc` or `Type.Method`), or a byte range (e.g., `100-250`),
+	// for the smallest enclosing declaration or statement; the whole file if empty.
+	GraphScope string
+
+	// GraphPerRound Whether to write a graph per round (`name.round-N.dot`) instead of overwriting DotFile
+	GraphPerRound bool
+
 	// ChainSplitDots Whether to split chain methods by putting dots at the ends of lines
 	ChainSplitDots bool
+
+	// Trace Whether to record the decisions of the shortener in the result (for debugging only)
+	Trace bool
+
+	// ExemptPatterns Patterns of unbreakable tokens (URLs, string literals, import paths, etc.)
+	// A line is not shortened if its excess length comes from a single token matching one of them.
+	ExemptPatterns []*regexp.Regexp
 }
 
+// Struct tag alignments.
+const (
+	// TagAlignmentNone keeps the struct tags as-is.
+	TagAlignmentNone = string(tags.AlignNone)
+
+	// TagAlignmentBlocks aligns the keys of the struct tags within each block of fields.
+	TagAlignmentBlocks = string(tags.AlignBlocks)
+
+	// TagAlignmentSpacing only normalizes the spacing between the keys of each struct tag.
+	TagAlignmentSpacing = string(tags.AlignSpacing)
+)
+
+// Graph formats.
+const (
+	// GraphFormatDot is the Graphviz (dot) format.
+	GraphFormatDot = graph.FormatDot
+
+	// GraphFormatJSON is a JSON tree of the nodes.
+	GraphFormatJSON = graph.FormatJSON
+
+	// GraphFormatMermaid is a Mermaid flowchart.
+	GraphFormatMermaid = graph.FormatMermaid
+
+	// GraphFormatHTML is a self-contained HTML page with a collapsible tree of the nodes.
+	GraphFormatHTML = graph.FormatHTML
+)
+
 // NewDefaultConfig returns a [Config] with default values.
 func NewDefaultConfig() *Config {
 	return &Config{
 		MaxLen:          100,
 		TabLen:          4,
+		MaxRounds:       DefaultMaxRounds,
 		KeepAnnotations: false,
 		ShortenComments: false,
 		ReformatTags:    true,
+		TagAlignment:    TagAlignmentBlocks,
 		DotFile:         "",
 		ChainSplitDots:  true,
 	}
@@ -76,6 +159,18 @@ type Shortener struct {
 	commentsShortener *comments.Shortener
 
 	logger Logger
+
+	// stats are the counters of the current call (see ProcessFile).
+	stats *Stats
+
+	// tracer records the decisions of the current call, with the Trace option.
+	tracer *tracer
+
+	// explainer tracks the explained line of the current call (see Explain).
+	explainer *explainer
+
+	// grapher writes the AST graphs of the current call (nil if disabled).
+	grapher *grapher
 }
 
 // NewShortener creates a new shortener instance from the provided config.
@@ -89,10 +184,11 @@ func NewShortener(config *Config, opts ...Options) *Shortener {
 		logger: &noopLogger{},
 	}
 
-	if config.ShortenComments {
+	if config.ShortenComments || config.MoveTrailingComments {
 		s.commentsShortener = &comments.Shortener{
-			MaxLen: config.MaxLen,
-			TabLen: config.TabLen,
+			MaxLen:    cmp.Or(config.CommentMaxLen, config.MaxLen),
+			TabLen:    config.TabLen,
+			Rebalance: config.RebalanceComments,
 		}
 	}
 
@@ -103,83 +199,236 @@ func NewShortener(config *Config, opts ...Options) *Shortener {
 	return s
 }
 
+// nested returns a copy of the shortener with another config, for a nested call of ProcessFile
+// (e.g., the idempotency check): the explained line is cleared,
+// the per-call state (stats, trace, graphs) is set by ProcessFile.
+func (s *Shortener) nested(config *Config) *Shortener {
+	c := *s
+	c.config = config
+	c.explainer = nil
+
+	return &c
+}
+
 // Process shortens the provided golang file content bytes.
 func (s *Shortener) Process(content []byte) ([]byte, error) {
+	result, err := s.ProcessFile("", content)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Content, nil
+}
+
+// ProcessFile shortens the provided golang file content bytes,
+// and reports the problems that don't prevent the shortening.
+// The filename is only used in the positions of the warnings and the syntax errors.
+func (s *Shortener) ProcessFile(filename string, content []byte) (*Result, error) {
+	return s.ProcessFileFrom(filename, content, content)
+}
+
+// ProcessFileFrom is ProcessFile for a content produced from the source of the file by another formatter
+// (e.g., goimports): the graph scope, and the positions of the syntax errors, are the ones of the source.
+func (s *Shortener) ProcessFileFrom(filename string, source, content []byte) (*Result, error) {
+	result, err := s.processFile(filename, source, content)
+	if err != nil && !bytes.Equal(source, content) {
+		var stageErr *Error
+		if errors.As(err, &stageErr) {
+			stageErr.Remap(source, content)
+		}
+	}
+
+	return result, err
+}
+
+func (s *Shortener) processFile(filename string, source, content []byte) (*Result, error) {
+	result := &Result{}
+
+	// The per-call state is set on a copy of the shortener: the nested calls start from a clean state.
+	s = s.withStats(&result.Stats)
+
+	s.tracer = nil
+
+	if s.config.Trace {
+		s.tracer = newTracer(filename, s.config)
+		s.tracer.explain = s.explainer
+	}
+
+	grapher, err := newGrapher(s.config, source)
+	if err != nil {
+		return nil, err
+	}
+
+	s.grapher = grapher
+
+	if s.config.ValidateTags {
+		result.Warnings = append(result.Warnings, validateTags(filename, content)...)
+	}
+
 	var round int
 
-	var err error
+	input := content
 
 	// Do initial, non-line-length-aware formatting
-	content, err = format.Source(content)
+	formatted, err := format.Source(content)
 	if err != nil {
-		return nil, fmt.Errorf("error formatting source: %w", err)
+		if s.config.Tolerant {
+			return s.processTolerant(filename, content, result, err)
+		}
+
+		return nil, NewError(StageFormat, filename, err)
 	}
 
+	content = formatted
+
+	original := content
+
+	var nbLongLines int
+
+	// The rounds are deterministic: if an annotated content comes back, the next rounds cycle.
+	seen := map[[sha256.Size]byte]int{}
+
+	var period int
+
 	for {
 		s.logger.Debug("starting round", slog.Int("round", round))
 
+		// Move the long trailing comments before shortening, so the code is shortened on its own.
+		// This is done in each round: splitting a statement reindents the lines of its function literals.
+		if s.config.MoveTrailingComments {
+			moved := s.commentsShortener.MoveTrailing(content)
+			s.count(ConstructComments, countNewLines(content, moved))
+			content = moved
+		}
+
 		// Annotate all long lines
 		lines := strings.Split(string(content), "\n")
 		annotatedLines, nbLinesToShorten := s.annotateLongLines(lines)
 
+		if round == 0 {
+			nbLongLines = nbLinesToShorten
+		}
+
 		if !s.shouldContinue(nbLinesToShorten, round, lines) {
 			s.logger.Debug("nothing more to shorten or reformat, stopping")
 
 			break
 		}
 
+		if round >= s.maxRounds() {
+			s.logger.Debug("hit max rounds, stopping", slog.Int("max_rounds", s.maxRounds()))
+
+			s.stats.MaxRoundsHit = true
+
+			break
+		}
+
 		content = []byte(strings.Join(annotatedLines, "\n"))
 
+		hash := sha256.Sum256(content)
+		if previous, ok := seen[hash]; ok {
+			period = round - previous
+
+			s.logger.Debug("the rounds cycle, stopping", slog.Int("round", round), slog.Int("period", period))
+
+			break
+		}
+
+		seen[hash] = round
+
 		// Generate AST
-		result, err := decorator.Parse(content)
+		dec := decorator.NewDecorator(token.NewFileSet())
+
+		file, err := dec.Parse(content)
 		if err != nil {
-			return nil, err
+			return nil, NewError(StageParse, filename, err).Remap(original, content).Remap(input, original)
 		}
 
-		if s.config.DotFile != "" {
-			err = s.createDot(result)
+		if s.tracer != nil {
+			s.tracer.startRound(round, annotatedLines, dec, file)
+		}
+
+		if s.grapher != nil {
+			err = s.createDot(round, lines, annotatedLines, dec, file)
 			if err != nil {
 				return nil, err
 			}
 		}
 
 		// Process the file.
-		s.formatFile(result)
+		s.formatFile(file)
 
 		// Materialize output
 		output := bytes.NewBuffer([]byte{})
 
-		err = decorator.Fprint(output, result)
+		restorer := decorator.NewRestorer()
+
+		err = restorer.Fprint(output, file)
 		if err != nil {
-			return nil, fmt.Errorf("error parsing source: %w", err)
+			return nil, NewError(StagePrint, filename, err)
+		}
+
+		if s.tracer != nil {
+			s.tracer.endRound(restorer, file, output.Bytes())
 		}
 
 		content = output.Bytes()
 
 		round++
+	}
 
-		if round > maxRounds {
-			s.logger.Debug("hit max rounds, stopping")
-
-			break
-		}
+	if s.config.CheckIdempotency && (period > 0 || s.stats.MaxRoundsHit) {
+		return nil, &ConvergenceError{Filename: filename, Rounds: round, MaxRounds: s.maxRounds(), Period: period}
 	}
 
 	if !s.config.KeepAnnotations {
 		content = removeAnnotations(content)
 	}
 
-	if s.commentsShortener != nil {
-		content = s.commentsShortener.Process(content)
+	if s.config.ShortenComments {
+		shortened := s.commentsShortener.Process(content)
+		s.count(ConstructComments, countNewLines(content, shortened))
+		content = shortened
 	}
 
 	// Do the final round of non-line-length-aware formatting after we've fixed up the comments
-	content, err = format.Source(content)
+	shortened, err := format.Source(content)
 	if err != nil {
-		return nil, fmt.Errorf("error formatting source: %w", err)
+		return nil, NewError(StageFinalFormat, filename, err).Remap(original, content).Remap(input, original)
+	}
+
+	content = shortened
+
+	overLimit, codeOverLimit := s.reportLongLines(content)
+
+	s.stats.Rounds = round
+	s.stats.LinesOverLimit = overLimit
+	s.stats.LinesShortened = max(nbLongLines-codeOverLimit, 0)
+
+	if s.config.Verify {
+		if reason := verify(original, content); reason != "" {
+			reproducer := minimize(original, func(candidate []byte) bool {
+				return s.verifyProcessing(filename, candidate) != ""
+			})
+
+			return nil, &VerifyError{Filename: filename, Reason: reason, Reproducer: reproducer}
+		}
 	}
 
-	return content, nil
+	if s.config.CheckIdempotency && !s.config.KeepAnnotations {
+		err = s.checkIdempotency(filename, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Content = content
+
+	if s.tracer != nil {
+		result.Trace = s.tracer.events
+	}
+
+	return result, nil
 }
 
 // shouldContinue returns true:
@@ -189,18 +438,14 @@ func (s *Shortener) Process(content []byte) ([]byte, error) {
 // and there are struct tags with multiple entries.
 func (s *Shortener) shouldContinue(nbLinesToShorten, round int, lines []string) bool {
 	return nbLinesToShorten > 0 ||
-		round == 0 && s.config.ReformatTags && tags.HasMultipleEntries(lines)
+		round == 0 && s.config.ReformatTags && (s.tagAlignment() != tags.AlignNone || len(s.config.TagOrder) > 0) &&
+			tags.HasMultipleEntries(lines)
 }
 
-func (s *Shortener) createDot(result dst.Node) error {
-	dotFile, err := os.Create(s.config.DotFile)
-	if err != nil {
-		return err
-	}
-
-	defer dotFile.Close()
-
-	s.logger.Debug("writing dot file output", slog.String("file", s.config.DotFile))
+func (s *Shortener) maxRounds() int {
+	return cmp.Or(s.config.MaxRounds, DefaultMaxRounds)
+}
 
-	return graph.CreateDot(result, dotFile)
+func (s *Shortener) tagAlignment() tags.Alignment {
+	return tags.Alignment(cmp.Or(s.config.TagAlignment, TagAlignmentBlocks))
 }
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"text/template"
 
+	"github.com/dave/dst"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -82,6 +83,226 @@ func TestShortener(t *testing.T) {
 	}
 }
 
+func TestShortener_ProcessFile_tagWarnings(t *testing.T) {
+	config := NewDefaultConfig()
+	config.ValidateTags = true
+
+	file := filepath.Join(testdataDir, "struct_tags_order", "struct_tags_order.go")
+
+	content, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	result, err := NewShortener(config).ProcessFile(file, content)
+	require.NoError(t, err)
+
+	var warnings []string
+
+	for _, warning := range result.Warnings {
+		warnings = append(warnings, warning.String())
+	}
+
+	expected := []string{
+		file + ":9:18: invalid struct tag value `json:\"nickname\" xml:nickname`: missing opening quote",
+		file + ":10:18: duplicate struct tag key \"json\"",
+	}
+
+	assert.Equal(t, expected, warnings)
+}
+
+func TestShortener_ProcessFile_stats(t *testing.T) {
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+
+	content := []byte(`package main
+
+type T struct {
+	A string ` + "`json:\"a\" yaml:\"a\"`" + `
+	LongFieldName string ` + "`json:\"long\" yaml:\"long\"`" + `
+}
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+	if aaaaaaaaaaaaaaaa && bbbbbbbbbbbbbbbbbb {
+	}
+	x := []string{"aaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbbb"}
+	_ = "a string literal that can't be shortened at all"
+}
+`)
+
+	result, err := NewShortener(config).ProcessFile("", content)
+	require.NoError(t, err)
+
+	expected := Stats{
+		LinesShortened: 3,
+		LinesOverLimit: 3,
+		Rounds:         1,
+		Constructs: map[Construct]int{
+			ConstructCallArgs:      1,
+			ConstructCompositeLits: 1,
+			ConstructConditions:    1,
+			ConstructTags:          1,
+		},
+	}
+
+	assert.Equal(t, expected, result.Stats)
+}
+
+func TestShortener_ProcessFile_checkIdempotency(t *testing.T) {
+	// The nested call needs 2 rounds.
+	content := []byte(`package main
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", fmt.Sprintf("%s %s", "bbbbbbbbbbbb", "cccccccccccc"))
+}
+`)
+
+	testCases := []struct {
+		desc      string
+		maxRounds int
+		expected  string
+	}{
+		{
+			desc:      "converges",
+			maxRounds: 2,
+		},
+		{
+			desc:      "round limit",
+			maxRounds: 1,
+			expected:  "main.go: the shortening doesn't converge: the round limit (1) was hit",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := NewDefaultConfig()
+			config.MaxLen = 40
+			config.MaxRounds = test.maxRounds
+			config.CheckIdempotency = true
+
+			_, err := NewShortener(config).ProcessFile("main.go", content)
+			if test.expected == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.EqualError(t, err, test.expected)
+
+			var convergenceErr *ConvergenceError
+			require.ErrorAs(t, err, &convergenceErr)
+
+			assert.Equal(t, 1, convergenceErr.Rounds)
+		})
+	}
+}
+
+func TestShortener_ProcessFile_maxRounds(t *testing.T) {
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+	config.MaxRounds = 1
+
+	content := []byte(`package main
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", fmt.Sprintf("%s %s", "bbbbbbbbbbbb", "cccccccccccc"))
+}
+`)
+
+	result, err := NewShortener(config).ProcessFile("main.go", content)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.Stats.Rounds)
+	assert.True(t, result.Stats.MaxRoundsHit)
+}
+
+func TestConvergenceError_Error(t *testing.T) {
+	err := &ConvergenceError{Filename: "main.go", Rounds: 5, MaxRounds: 20, Period: 2}
+
+	assert.EqualError(t, err,
+		"main.go: the shortening doesn't converge: the rounds cycle with a period of 2 rounds, after 5 rounds")
+}
+
+func TestIdempotencyError_Error(t *testing.T) {
+	err := &IdempotencyError{Line: 3, Output: "\tfoo(a, b)", Reprocessed: "\tfoo("}
+
+	assert.EqualError(t, err,
+		`the shortening is not idempotent: line 3 changes when the output is processed again: "\tfoo(a, b)" -> "\tfoo("`)
+}
+
+func TestShortener_formatExpr_nilFieldLists(t *testing.T) {
+	s := NewShortener(nil)
+
+	assert.NotPanics(t, func() {
+		s.formatExpr(&dst.InterfaceType{}, true, false)
+		s.formatExpr(&dst.FuncType{}, true, false)
+		s.formatDecl(&dst.FuncDecl{Name: dst.NewIdent("f"), Type: &dst.FuncType{}})
+	})
+}
+
+func TestShortener_ProcessFile_trace(t *testing.T) {
+	content := []byte(`package main
+
+func main() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+	_ = "a string literal that can't be shortened at all"
+}
+`)
+
+	expected := []TraceEvent{
+		{
+			File:         "main.go",
+			Line:         4,
+			Column:       2,
+			Node:         "CallExpr",
+			Rule:         RuleCallArgs,
+			LengthBefore: 63,
+			LengthAfter:  23,
+			Converged:    true,
+		},
+		{
+			File:         "main.go",
+			Line:         5,
+			Column:       6,
+			Node:         "BasicLit",
+			Rule:         RuleUnsupported,
+			LengthBefore: 57,
+			LengthAfter:  57,
+		},
+	}
+
+	testCases := []struct {
+		desc             string
+		checkIdempotency bool
+	}{
+		{
+			desc: "trace",
+		},
+		{
+			// The second pass is not traced.
+			desc:             "check idempotency",
+			checkIdempotency: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := NewDefaultConfig()
+			config.MaxLen = 40
+			config.Trace = true
+			config.CheckIdempotency = test.checkIdempotency
+
+			result, err := NewShortener(config).ProcessFile("main.go", content)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected, result.Trace)
+		})
+	}
+}
+
 func loadTestCases(t *testing.T) map[string]*Config {
 	t.Helper()
 
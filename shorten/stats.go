@@ -0,0 +1,146 @@
+package shorten
+
+import (
+	"strings"
+
+	"github.com/dave/dst"
+)
+
+// Construct is a kind of code construct split (or reformatted) by the shortener.
+type Construct string
+
+// Kinds of constructs.
+const (
+	// ConstructCallArgs is a call with its arguments on their own lines.
+	ConstructCallArgs Construct = "call_args"
+
+	// ConstructChains is a chain of method calls split on the dots.
+	ConstructChains Construct = "chains"
+
+	// ConstructCompositeLits is a composite literal with its elements on their own lines.
+	ConstructCompositeLits Construct = "composite_literals"
+
+	// ConstructSignatures is a function signature with its parameters on their own lines.
+	ConstructSignatures Construct = "signatures"
+
+	// ConstructConditions is a condition (`&&`, `||`, or case clause) split on several lines.
+	ConstructConditions Construct = "conditions"
+
+	// ConstructTags is a reformatted struct tag.
+	ConstructTags Construct = "tags"
+
+	// ConstructComments is a comment line rewritten (wrapped or moved) by the comment shortener.
+	ConstructComments Construct = "comments"
+)
+
+// Stats are the counters of the shortening of a file.
+type Stats struct {
+	// LinesShortened The number of long lines that are no longer over the limit
+	LinesShortened int
+
+	// LinesOverLimit The number of lines still over the limit, except the exempt ones
+	LinesOverLimit int
+
+	// Rounds The number of shortening rounds
+	Rounds int
+
+	// MaxRoundsHit Whether the shortening stopped because of the maximum number of rounds
+	MaxRoundsHit bool
+
+	// Constructs The number of constructs split or reformatted, by kind
+	Constructs map[Construct]int
+}
+
+// withStats returns a copy of the shortener that records its counters in stats:
+// the shortener is shared by the concurrent calls.
+func (s *Shortener) withStats(stats *Stats) *Shortener {
+	c := *s
+	c.stats = stats
+
+	return &c
+}
+
+// count records a construct split or reformatted.
+func (s *Shortener) count(construct Construct, n int) {
+	if s.stats == nil || n == 0 {
+		return
+	}
+
+	if s.stats.Constructs == nil {
+		s.stats.Constructs = map[Construct]int{}
+	}
+
+	s.stats.Constructs[construct] += n
+}
+
+// merge adds the counters of the shortening of a part of a file.
+func (st *Stats) merge(other Stats) {
+	st.LinesShortened += other.LinesShortened
+	st.LinesOverLimit += other.LinesOverLimit
+	st.Rounds = max(st.Rounds, other.Rounds)
+	st.MaxRoundsHit = st.MaxRoundsHit || other.MaxRoundsHit
+
+	if len(other.Constructs) > 0 && st.Constructs == nil {
+		st.Constructs = map[Construct]int{}
+	}
+
+	for construct, n := range other.Constructs {
+		st.Constructs[construct] += n
+	}
+}
+
+// fieldTags returns the tags of the fields of a struct.
+func fieldTags(fieldList *dst.FieldList) []string {
+	if fieldList == nil {
+		return nil
+	}
+
+	var result []string
+
+	for _, field := range fieldList.List {
+		if field.Tag != nil {
+			result = append(result, field.Tag.Value)
+		} else {
+			result = append(result, "")
+		}
+	}
+
+	return result
+}
+
+// countChanged counts the elements changed between two versions of a list of the same length.
+func countChanged(before, after []string) int {
+	var n int
+
+	for i := range min(len(before), len(after)) {
+		if before[i] != after[i] {
+			n++
+		}
+	}
+
+	return n
+}
+
+// countNewLines counts the lines of the new content that aren't in the old one
+// (e.g., the comment lines rewritten by the comment shortener).
+func countNewLines(before, after []byte) int {
+	lines := map[string]int{}
+
+	for line := range strings.Lines(string(before)) {
+		lines[strings.TrimSuffix(line, "\n")]++
+	}
+
+	var n int
+
+	for line := range strings.Lines(string(after)) {
+		line = strings.TrimSuffix(line, "\n")
+
+		if lines[line] > 0 {
+			lines[line]--
+		} else {
+			n++
+		}
+	}
+
+	return n
+}
@@ -13,7 +13,7 @@ import "fmt"
 // Another comment
 
 /*
-	A block comment. Really long lines in here aren't currently processed because they're a bit harder to handle.
+	A block comment. Really long lines in here are rewrapped, but the code snippets are kept as-is because they're indented.
 
 	func(aReallyLongArgument string, anotherReallyLongArgument string, aThirdReallyLongArgument string) (string, error) {
 		return "", nil
@@ -0,0 +1,24 @@
+package fixtures
+
+import "fmt"
+
+func directives() {
+	result, err := client.Do(ctx, req, argument1, argument2, argument3, argument4) //nolint:errcheck // reason
+
+	client.Do(ctx, req, argument1, argument2, argument3, argument4, argument5, argument6) //nolint:errcheck
+
+	fmt.Println(map[string]string{"key1": "value1", "key2": "value2", "key3": "value3", "key4": "value4"}) //nolint
+
+	return client.Do(ctx, req, argument1, argument2, argument3, argument4, argument5) //lint:ignore SA1019 reason
+
+	if argument1 == "this is a very long condition" && argument2 == "this is another long condition" { //nolint:gocritic
+		fmt.Println("ok")
+	}
+
+	// Not a directive: the comment stays at the end of the statement.
+	client.Do(ctx, req, argument1, argument2, argument3, argument4, argument5, argument6) // not a directive
+
+	// Method chains split on the dots can't have a comment at the end of their first line,
+	// the directive stays on the last line.
+	x := myObj.Method(argument1, argument2).AnotherMethod(argument3, argument4).AThirdMethod(argument5) //nolint
+}
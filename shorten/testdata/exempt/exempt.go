@@ -0,0 +1,23 @@
+package fixtures
+
+import (
+	"fmt"
+
+	averyveryveryveryverylongpackagename "github.com/example/a-very-very-very-very-very-long-repository-name/pkg"
+)
+
+func exempt() {
+	// The URL is the only reason this line is long, there's nothing to shorten.
+	fmt.Println("short") // https://github.com/golangci/golines/blob/master/shorten/testdata/exempt/exempt.go
+
+	// The string literal is the only reason this line is long.
+	fmt.Println("This is a really long string literal that can't be shortened without changing its value.")
+
+	// This line is long because of its arguments, it should be shortened.
+	fmt.Printf("%s %s %s %s %s %s", "argument1", "argument2", "argument3", "argument4", "argument5", "argument6")
+
+	// This line is long because of its arguments, the URL isn't enough to exempt it.
+	fmt.Println("https://example.com", "argument1", "argument2", "argument3", "argument4", "argument5", "argument6", "argument7")
+
+	averyveryveryveryverylongpackagename.Do()
+}
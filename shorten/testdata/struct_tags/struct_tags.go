@@ -1,6 +1,10 @@
 package fixtures
 
-import "fmt"
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
 
 type MyStruct struct {
 	Field1 string `json:"field1" info:"something"`
@@ -68,13 +72,22 @@ type Struct9 struct {
 	Field2   string `json:"field"`
 }
 
-// Width of function types isn't supported, so MyStruct tags will not be aligned
+// Width of function types is measured on their printed form
 type Struct10 struct {
 	Field1   func(int, int) string `json:"field" info:"value"`
 	Field2   string                `info:"value2"`
 	MyStruct `json:"field"   info:"value3"`
 }
 
+// Package-qualified and generic types are aligned too
+type Struct12 struct {
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+	Current atomic.Pointer[MyStruct] `json:"current" yaml:"current"`
+	Empty struct{} `json:"empty" yaml:"empty"`
+	Any interface{} `json:"any" yaml:"any"`
+	MyStruct `json:"my_struct" yaml:"my_struct"`
+}
+
 // Consistent behavior with MyStruct11
 type MyStruct10 struct {
 	Field1 string `a:"field1" b:"something"`
@@ -0,0 +1,11 @@
+package fixtures
+
+import "time"
+
+type MyStruct struct {
+	Field1 string `json:"field1"   info:"something"`
+	Field2 string `info:"something else"   json:"field2 long value"`
+	Field3 string `  info:"third thing" json:"field3"   `
+
+	Timeout time.Duration `yaml:"timeout"    json:"timeout"`
+}
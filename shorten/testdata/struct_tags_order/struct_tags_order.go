@@ -0,0 +1,15 @@
+package fixtures
+
+type User struct {
+	ID    int    `db:"id" json:"id"`
+	Name  string `validate:"required" xml:"name" json:"name" db:"name"`
+	Email string `bson:"email" yaml:"email" json:"email,omitempty" validate:"email"`
+
+	// The malformed tags are kept as-is.
+	Nickname string `json:"nickname" xml:nickname`
+	Age      int    `json:"age" json:"years"`
+}
+
+type Single struct {
+	Value string `yaml:"value"`
+}
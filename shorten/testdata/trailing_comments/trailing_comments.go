@@ -0,0 +1,22 @@
+package fixtures
+
+import "fmt"
+
+type Config struct {
+	Name    string // The name is used to identify the configuration in the logs and in the error messages.
+	Timeout int    // in seconds
+}
+
+func trailingComments() {
+	fmt.Println("argument1", "argument2") // This comment is long enough to push the line over the limit.
+
+	fmt.Printf("%s %s %s %s %s %s", "argument1", "argument2", "argument3", "argument4") // explanation
+
+	fmt.Println("argument1", "argument2") //nolint:forbidigo // This directive must stay on its line.
+}
+
+func nestedTrailingComments() {
+	register("a route name that is long enough to push this line over the maximum line length", func(w Writer) {
+		w.Flush() // send the headers and the body now: this line only fits before the call is split
+	})
+}
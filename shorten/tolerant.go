@@ -0,0 +1,126 @@
+package shorten
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+)
+
+// processTolerant shortens the top-level declarations of a file with syntax errors, with the Tolerant option.
+// The declarations that contain a syntax error, and the text between the declarations, are kept unchanged,
+// and the syntax errors are reported as warnings.
+func (s *Shortener) processTolerant(filename string, content []byte, result *Result, formatErr error) (*Result, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, filename, content, parser.ParseComments|parser.AllErrors)
+
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 || file == nil || file.Name == nil ||
+		!file.Name.Pos().IsValid() || file.Name.Name == "_" {
+		// Not a syntax error, or a broken package clause: nothing can be shortened.
+		return nil, NewError(StageFormat, filename, formatErr)
+	}
+
+	for _, syntaxErr := range list {
+		result.Warnings = append(result.Warnings, Warning{
+			Pos:     syntaxErr.Pos,
+			Message: "syntax error: " + syntaxErr.Msg + " (the declaration is kept unchanged)",
+		})
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	var (
+		output bytes.Buffer
+		cursor int
+	)
+
+	for _, decl := range file.Decls {
+		start, end := decl.Pos(), decl.End()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+
+		if _, bad := decl.(*ast.BadDecl); bad || !start.IsValid() || !end.IsValid() {
+			continue
+		}
+
+		// The positions of a broken declaration can be out of order.
+		startOffset, endOffset := offset(start), offset(end)
+		if startOffset < cursor || endOffset <= startOffset || hasSyntaxError(list, startOffset, endOffset) {
+			continue
+		}
+
+		shortened, ok := s.processDecl(filename, file.Name.Name, content[startOffset:endOffset],
+			fset.Position(start).Line, result)
+		if !ok {
+			continue
+		}
+
+		output.Write(content[cursor:startOffset])
+		output.Write(shortened)
+
+		cursor = endOffset
+	}
+
+	output.Write(content[cursor:])
+
+	result.Content = output.Bytes()
+
+	return result, nil
+}
+
+// hasSyntaxError returns true if a syntax error is within a range of offsets.
+func hasSyntaxError(list scanner.ErrorList, start, end int) bool {
+	for _, syntaxErr := range list {
+		if syntaxErr.Pos.Offset >= start && syntaxErr.Pos.Offset <= end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// processDecl shortens a declaration on its own, in a file with only a package clause.
+// The line is the line of the declaration in the file, for the positions of the warnings and the trace.
+// It returns false if the declaration can't be shortened on its own.
+func (s *Shortener) processDecl(filename, pkg string, decl []byte, line int, result *Result) ([]byte, bool) {
+	header := []byte("package " + pkg + "\n\n")
+
+	// The declaration starts at the line 3 of its file.
+	lineOffset := line - 3
+
+	config := *s.config
+	config.Tolerant = false
+	config.DotFile = ""
+
+	declResult, err := s.nested(&config).ProcessFile(filename, slices.Concat(header, decl, []byte("\n")))
+	if err != nil {
+		s.logger.Debug("the declaration can't be shortened on its own")
+
+		return nil, false
+	}
+
+	shortened, ok := bytes.CutPrefix(declResult.Content, header)
+	if !ok {
+		return nil, false
+	}
+
+	for _, warning := range declResult.Warnings {
+		warning.Pos.Line += lineOffset
+		result.Warnings = append(result.Warnings, warning)
+	}
+
+	for _, event := range declResult.Trace {
+		event.Line += lineOffset
+		result.Trace = append(result.Trace, event)
+	}
+
+	s.stats.merge(declResult.Stats)
+
+	return bytes.TrimSuffix(shortened, []byte("\n")), true
+}
@@ -0,0 +1,85 @@
+package shorten
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortener_ProcessFile_tolerant(t *testing.T) {
+	broken := `func broken( {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+`
+
+	content := `package main
+
+import "fmt"
+
+// short prints.
+func short() {
+	fmt.Println("aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc")
+}
+
+` + broken + `
+var list = []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc"}
+`
+
+	expected := `package main
+
+import "fmt"
+
+// short prints.
+func short() {
+	fmt.Println(
+		"aaaaaaaaaaaa",
+		"bbbbbbbbbbbb",
+		"cccccccccccc",
+	)
+}
+
+` + broken + `
+var list = []string{
+	"aaaaaaaaaaaa",
+	"bbbbbbbbbbbb",
+	"cccccccccccc",
+}
+`
+
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+
+	_, err := NewShortener(config).ProcessFile("main.go", []byte(content))
+
+	var stageErr *Error
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, StageFormat, stageErr.Stage)
+
+	config.Tolerant = true
+
+	result, err := NewShortener(config).ProcessFile("main.go", []byte(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(result.Content))
+
+	require.NotEmpty(t, result.Warnings)
+	assert.Equal(t, "main.go:10:14: syntax error: expected ')', found '{' (the declaration is kept unchanged)",
+		result.Warnings[0].String())
+
+	assert.Equal(t, 2, result.Stats.LinesShortened)
+	assert.Equal(t, map[Construct]int{ConstructCallArgs: 1, ConstructCompositeLits: 1}, result.Stats.Constructs)
+}
+
+func TestShortener_ProcessFile_tolerantPackageClause(t *testing.T) {
+	content := []byte("package\n\nfunc main() {}\n")
+
+	config := NewDefaultConfig()
+	config.Tolerant = true
+
+	_, err := NewShortener(config).ProcessFile("main.go", content)
+
+	var stageErr *Error
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, StageFormat, stageErr.Stage)
+}
@@ -0,0 +1,273 @@
+package shorten
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+
+	"github.com/dave/dst"
+	"github.com/dave/dst/decorator"
+	"github.com/golangci/golines/shorten/internal"
+	"github.com/golangci/golines/shorten/internal/annotation"
+)
+
+// Rule is a decision of the shortener on a node.
+type Rule string
+
+// Rules of the trace events.
+const (
+	// RuleCallArgs puts the arguments of a call on their own lines.
+	RuleCallArgs Rule = "call_args"
+
+	// RuleChain splits a chain of method calls on the dots.
+	RuleChain Rule = "chain"
+
+	// RuleCompositeLit puts the elements of a composite literal on their own lines.
+	RuleCompositeLit Rule = "composite_lit"
+
+	// RuleSignature puts the parameters of a function on their own lines.
+	RuleSignature Rule = "signature"
+
+	// RuleCondition splits a condition before the right operand of `&&` or `||`.
+	RuleCondition Rule = "condition"
+
+	// RuleCaseList puts the expressions of a case clause on their own lines.
+	RuleCaseList Rule = "case_list"
+
+	// RuleStructTags reformats the tags of a struct.
+	RuleStructTags Rule = "struct_tags"
+
+	// RuleUnsupported is a long node that can't be shortened.
+	RuleUnsupported Rule = "unsupported"
+)
+
+// TraceEvent is a decision of the shortener, recorded with the Trace option.
+//
+// The JSON format of the events is stable: fields may be added, but the existing ones are kept.
+type TraceEvent struct {
+	// File The name of the file
+	File string `json:"file"`
+
+	// Round The shortening round, starting at 0
+	Round int `json:"round"`
+
+	// Line The line of the node in the content at the start of the round (without the annotations)
+	Line int `json:"line"`
+
+	// Column The column of the node (in bytes, starting at 1)
+	Column int `json:"column"`
+
+	// Node The kind of the node (e.g., CallExpr)
+	Node string `json:"node"`
+
+	// Rule The decision applied to the node
+	Rule Rule `json:"rule"`
+
+	// LengthBefore The length of the line of the node at the start of the round (the annotated length)
+	LengthBefore int `json:"length_before"`
+
+	// LengthAfter The length of the longest line of the node at the end of the round
+	LengthAfter int `json:"length_after"`
+
+	// Converged Whether all the lines of the node fit in the maximum length at the end of the round
+	Converged bool `json:"converged"`
+}
+
+// tracer records the decisions of a call of the shortener.
+type tracer struct {
+	filename string
+	maxLen   int
+	tabLen   int
+
+	round int
+
+	// lines are the annotated lines of the round.
+	lines []string
+
+	decorator *decorator.Decorator
+
+	events []TraceEvent
+
+	// nodes are the nodes of the events of the round.
+	nodes []dst.Node
+
+	// explain tracks an explained line (see Shortener.Explain).
+	explain *explainer
+}
+
+func newTracer(filename string, config *Config) *tracer {
+	return &tracer{
+		filename: filename,
+		maxLen:   config.MaxLen,
+		tabLen:   config.TabLen,
+	}
+}
+
+// startRound starts recording the decisions on the file parsed by the decorator.
+func (t *tracer) startRound(round int, lines []string, dec *decorator.Decorator, file *dst.File) {
+	t.round = round
+	t.lines = lines
+	t.decorator = dec
+	t.nodes = nil
+
+	if t.explain != nil {
+		t.explain.startRound(t, file)
+	}
+}
+
+// record records a decision on a node.
+func (t *tracer) record(node dst.Node, rule Rule) {
+	event := TraceEvent{
+		File:  t.filename,
+		Round: t.round,
+		Node:  strings.TrimPrefix(reflect.TypeOf(node).String(), "*dst."),
+		Rule:  rule,
+	}
+
+	if n, ok := t.decorator.Ast.Nodes[node]; ok && n.Pos().IsValid() {
+		pos := t.decorator.Fset.Position(n.Pos())
+
+		if pos.Line <= len(t.lines) {
+			event.LengthBefore = internal.LineLength(t.lines[pos.Line-1], t.tabLen)
+		}
+
+		event.Line = pos.Line - countAnnotations(t.lines[:min(pos.Line-1, len(t.lines))])
+		event.Column = pos.Column
+	}
+
+	t.events = append(t.events, event)
+	t.nodes = append(t.nodes, node)
+}
+
+// endRound completes the events of the round with the lengths of the lines of their nodes in the output.
+func (t *tracer) endRound(restorer *decorator.Restorer, file *dst.File, output []byte) {
+	positions := newOutputPositions(restorer, file, output)
+
+	lines := strings.Split(string(output), "\n")
+
+	events := t.events[len(t.events)-len(t.nodes):]
+
+	for i, node := range t.nodes {
+		start, end, ok := positions.lines(node)
+		if !ok {
+			continue
+		}
+
+		for _, line := range lines[min(start-1, len(lines)):min(end, len(lines))] {
+			if annotation.Is(line) {
+				continue
+			}
+
+			events[i].LengthAfter = max(events[i].LengthAfter, internal.LineLength(line, t.tabLen))
+		}
+
+		events[i].Converged = events[i].LengthAfter <= t.maxLen
+	}
+
+	t.nodes = nil
+
+	if t.explain != nil {
+		t.explain.endRound(t, positions, lines)
+	}
+}
+
+// outputPositions gives the lines of the nodes of a round in the printed output.
+//
+// The positions of the restored nodes don't match the printed lines (the printer reflows the code),
+// so the output is parsed again, and the nodes are matched by their order in the syntax tree:
+// the printing only changes the positions.
+type outputPositions struct {
+	restorer *decorator.Restorer
+
+	// index is the order of the restored nodes.
+	index map[ast.Node]int
+
+	fset   *token.FileSet
+	parsed []ast.Node
+}
+
+func newOutputPositions(restorer *decorator.Restorer, file *dst.File, output []byte) *outputPositions {
+	p := &outputPositions{
+		restorer: restorer,
+		index:    map[ast.Node]int{},
+		fset:     token.NewFileSet(),
+	}
+
+	restored, ok := restorer.Ast.Nodes[file]
+	if !ok {
+		return p
+	}
+
+	parsed, err := parser.ParseFile(p.fset, "", output, parser.ParseComments)
+	if err != nil {
+		return p
+	}
+
+	for i, n := range syntaxNodes(restored) {
+		p.index[n] = i
+	}
+
+	p.parsed = syntaxNodes(parsed)
+
+	return p
+}
+
+// lines returns the first and the last lines of a node in the output.
+func (p *outputPositions) lines(node dst.Node) (start, end int, ok bool) {
+	n, ok := p.restorer.Ast.Nodes[node]
+	if !ok {
+		return 0, 0, false
+	}
+
+	i, ok := p.index[n]
+	if !ok || i >= len(p.parsed) {
+		return 0, 0, false
+	}
+
+	parsed := p.parsed[i]
+
+	return p.fset.Position(parsed.Pos()).Line, p.fset.Position(parsed.End()).Line, true
+}
+
+// syntaxNodes returns the nodes of a syntax tree in depth-first order, without the comments.
+func syntaxNodes(root ast.Node) []ast.Node {
+	var nodes []ast.Node
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil:
+			return false
+
+		case *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+
+		nodes = append(nodes, n)
+
+		return true
+	})
+
+	return nodes
+}
+
+// countAnnotations counts the annotation lines.
+func countAnnotations(lines []string) int {
+	var n int
+
+	for _, line := range lines {
+		if annotation.Is(line) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// trace records a decision on a node, with the Trace option.
+func (s *Shortener) trace(node dst.Node, rule Rule) {
+	if s.tracer != nil {
+		s.tracer.record(node, rule)
+	}
+}
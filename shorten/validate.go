@@ -0,0 +1,47 @@
+package shorten
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+
+	"github.com/golangci/golines/shorten/internal/tags"
+)
+
+// validateTags reports the malformed struct tags, the duplicate keys, and the unquoted values.
+// The positions are the ones of the tags in the provided content.
+func validateTags(filename string, content []byte) []Warning {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, filename, content, parser.SkipObjectResolution)
+	if err != nil {
+		// The syntax errors are reported by the formatting.
+		return nil
+	}
+
+	var warnings []Warning
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		field, ok := node.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+
+		for _, problem := range tags.Validate(tag) {
+			warnings = append(warnings, Warning{
+				Pos:     fset.Position(field.Tag.Pos()),
+				Message: problem,
+			})
+		}
+
+		return true
+	})
+
+	return warnings
+}
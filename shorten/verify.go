@@ -0,0 +1,328 @@
+package shorten
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/golangci/golines/shorten/internal/annotation"
+	"github.com/golangci/golines/shorten/internal/tags"
+)
+
+// VerifyError reports that the shortening changed the program, with the Verify option.
+// It's an internal error of the shortener.
+type VerifyError struct {
+	// Filename The name of the file
+	Filename string
+
+	// Reason The difference between the input and the output
+	Reason string
+
+	// Reproducer A minimized input reproducing the problem (nil if the whole file is needed)
+	Reproducer []byte
+}
+
+func (e *VerifyError) Error() string {
+	message := withFilename(e.Filename,
+		"internal error: the shortening changed the program, the file is kept unchanged: "+e.Reason)
+
+	if len(e.Reproducer) == 0 {
+		return message + "\nplease report it with the file as a reproducer"
+	}
+
+	return message + "\nplease report it with this minimized reproducer:\n" + string(e.Reproducer)
+}
+
+var (
+	posType          = reflect.TypeFor[token.Pos]()
+	commentGroupType = reflect.TypeFor[*ast.CommentGroup]()
+	objectType       = reflect.TypeFor[*ast.Object]()
+	scopeType        = reflect.TypeFor[*ast.Scope]()
+	fileType         = reflect.TypeFor[ast.File]()
+)
+
+// derivedFileFields are the fields of a file derived from its declarations, or from the comments.
+var derivedFileFields = []string{"Comments", "Imports", "Unresolved"}
+
+// verify compares the input and the output of the shortener:
+// their syntax trees (ignoring the positions, and the formatting of the struct tags),
+// and the words of their comments.
+// It returns a description of the first difference, or an empty string.
+func verify(input, output []byte) string {
+	const mode = parser.ParseComments | parser.SkipObjectResolution
+
+	inputFile, err := parser.ParseFile(token.NewFileSet(), "", input, mode)
+	if err != nil {
+		// The input is checked by the shortener first.
+		return "the input doesn't parse: " + err.Error()
+	}
+
+	outputFile, err := parser.ParseFile(token.NewFileSet(), "", output, mode)
+	if err != nil {
+		return "the output doesn't parse: " + err.Error()
+	}
+
+	reason := compareValues(reflect.ValueOf(inputFile), reflect.ValueOf(outputFile), "File")
+	if reason != "" {
+		return reason
+	}
+
+	return compareComments(inputFile, outputFile)
+}
+
+// compareValues compares two values of the syntax trees, and describes the first difference.
+// The positions are only compared on their validity, because some nodes are only defined by them
+// (e.g., the ellipsis of a call).
+func compareValues(a, b reflect.Value, path string) string {
+	if a.Type() != b.Type() {
+		return fmt.Sprintf("%s: %s became %s", path, a.Type(), b.Type())
+	}
+
+	switch a.Type() {
+	case posType:
+		if a.Interface().(token.Pos).IsValid() != b.Interface().(token.Pos).IsValid() {
+			return path + ": a token was added or removed"
+		}
+
+		return ""
+
+	case commentGroupType, objectType, scopeType:
+		// The comments are compared separately, the objects are not resolved.
+		return ""
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				return path + ": a node was added or removed"
+			}
+
+			return ""
+		}
+
+		return compareValues(a.Elem(), b.Elem(), path)
+
+	case reflect.Struct:
+		if field, ok := a.Interface().(ast.Field); ok {
+			return compareField(field, b.Interface().(ast.Field), a, b, path)
+		}
+
+		for i := range a.NumField() {
+			name := a.Type().Field(i).Name
+
+			if a.Type() == fileType && slices.Contains(derivedFileFields, name) {
+				continue
+			}
+
+			reason := compareValues(a.Field(i), b.Field(i), path+"."+name)
+			if reason != "" {
+				return reason
+			}
+		}
+
+		return ""
+
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			return fmt.Sprintf("%s: %d elements became %d", path, a.Len(), b.Len())
+		}
+
+		for i := range a.Len() {
+			reason := compareValues(a.Index(i), b.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			if reason != "" {
+				return reason
+			}
+		}
+
+		return ""
+
+	default:
+		if !a.Equal(b) {
+			return fmt.Sprintf("%s: %v became %v", path, a.Interface(), b.Interface())
+		}
+
+		return ""
+	}
+}
+
+// compareField compares two struct fields, with the canonical form of their tags.
+func compareField(fa, fb ast.Field, a, b reflect.Value, path string) string {
+	if (fa.Tag == nil) != (fb.Tag == nil) {
+		return path + ".Tag: a tag was added or removed"
+	}
+
+	if fa.Tag != nil && tags.Canonical(fa.Tag.Value) != tags.Canonical(fb.Tag.Value) {
+		return fmt.Sprintf("%s.Tag: %s became %s", path, fa.Tag.Value, fb.Tag.Value)
+	}
+
+	for i := range a.NumField() {
+		name := a.Type().Field(i).Name
+		if name == "Tag" {
+			continue
+		}
+
+		reason := compareValues(a.Field(i), b.Field(i), path+"."+name)
+		if reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+// compareComments checks that all the words of the comments of the input are in the comments of the output.
+// The words are compared, and not the comments, because the comments can be wrapped or moved.
+func compareComments(input, output *ast.File) string {
+	available := map[string]int{}
+
+	for _, group := range output.Comments {
+		for _, c := range group.List {
+			if annotation.Is(c.Text) {
+				continue
+			}
+
+			for _, word := range commentWords(c.Text) {
+				available[word]++
+			}
+		}
+	}
+
+	for _, group := range input.Comments {
+		for _, c := range group.List {
+			for _, word := range commentWords(c.Text) {
+				if available[word] == 0 {
+					return fmt.Sprintf("the comment %q was lost", c.Text)
+				}
+
+				available[word]--
+			}
+		}
+	}
+
+	return ""
+}
+
+// commentWords returns the words of a comment, without its markers.
+func commentWords(text string) []string {
+	text = strings.TrimPrefix(text, "//")
+	text = strings.TrimPrefix(text, "/*")
+	text = strings.TrimSuffix(text, "*/")
+
+	return strings.Fields(text)
+}
+
+// verifyProcessing shortens a content, and verifies the result.
+// It returns a description of the first difference, or an empty string.
+func (s *Shortener) verifyProcessing(filename string, content []byte) string {
+	config := *s.config
+	config.Verify = false
+	config.CheckIdempotency = false
+	config.Trace = false
+	config.DotFile = ""
+
+	c := s.nested(&config)
+
+	formatted, err := format.Source(content)
+	if err != nil {
+		return ""
+	}
+
+	result, err := c.ProcessFile(filename, formatted)
+	if err != nil {
+		return ""
+	}
+
+	return verify(formatted, result.Content)
+}
+
+// minimize returns the smallest part of a content that reproduces a failure:
+// a single declaration (with the package clause and the imports), and, for a function, a single statement.
+// It returns nil if no such part reproduces the failure.
+func minimize(content []byte, reproduces func([]byte) bool) []byte {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+	// The package clause, and the imports.
+	header := content[:offset(file.Name.End())]
+
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			header = content[:offset(gen.End())]
+		}
+	}
+
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+
+		start := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			start = doc.Pos()
+		}
+
+		candidate := join(header, content[offset(start):offset(decl.End())])
+		if !reproduces(candidate) {
+			continue
+		}
+
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			return formatReproducer(candidate)
+		}
+
+		for _, stmt := range fn.Body.List {
+			reduced := join(header, slices.Concat(
+				content[offset(start):offset(fn.Body.Lbrace)+1],
+				[]byte("\n"),
+				content[offset(stmt.Pos()):offset(stmt.End())],
+				[]byte("\n}"),
+			))
+
+			if reproduces(reduced) {
+				return formatReproducer(reduced)
+			}
+		}
+
+		return formatReproducer(candidate)
+	}
+
+	return nil
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	default:
+		return nil
+	}
+}
+
+func join(header, body []byte) []byte {
+	return slices.Concat(header, []byte("\n\n"), body, []byte("\n"))
+}
+
+func formatReproducer(content []byte) []byte {
+	formatted, err := format.Source(content)
+	if err != nil {
+		return content
+	}
+
+	return bytes.TrimSpace(formatted)
+}
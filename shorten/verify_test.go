@@ -0,0 +1,135 @@
+package shorten
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_verify(t *testing.T) {
+	input := "package main\n\n" +
+		"type T struct {\n\tA string `yaml:\"a\" json:\"a\"`\n}\n\n" +
+		"func main() {\n\t// Print the values.\n\tfmt.Println(a, b, c...)\n}\n"
+
+	testCases := []struct {
+		desc     string
+		output   string
+		expected string
+	}{
+		{
+			desc: "split and aligned",
+			output: "package main\n\n" +
+				"type T struct {\n\tA string `json:\"a\" yaml:\"a\"`\n}\n\n" +
+				"func main() {\n\t// Print\n\t// the values.\n\tfmt.Println(\n\t\ta,\n\t\tb,\n\t\tc...,\n\t)\n}\n",
+		},
+		{
+			desc: "argument removed",
+			output: "package main\n\n" +
+				"type T struct {\n\tA string `yaml:\"a\" json:\"a\"`\n}\n\n" +
+				"func main() {\n\t// Print the values.\n\tfmt.Println(a, b)\n}\n",
+			expected: "File.Decls[1].Body.List[0].X.Args: 3 elements became 2",
+		},
+		{
+			desc: "ellipsis removed",
+			output: "package main\n\n" +
+				"type T struct {\n\tA string `yaml:\"a\" json:\"a\"`\n}\n\n" +
+				"func main() {\n\t// Print the values.\n\tfmt.Println(a, b, c)\n}\n",
+			expected: "File.Decls[1].Body.List[0].X.Ellipsis: a token was added or removed",
+		},
+		{
+			desc: "tag changed",
+			output: "package main\n\n" +
+				"type T struct {\n\tA string `json:\"b\" yaml:\"a\"`\n}\n\n" +
+				"func main() {\n\t// Print the values.\n\tfmt.Println(a, b, c...)\n}\n",
+			expected: "File.Decls[0].Specs[0].Type.Fields.List[0].Tag: " +
+				"`yaml:\"a\" json:\"a\"` became `json:\"b\" yaml:\"a\"`",
+		},
+		{
+			desc: "comment lost",
+			output: "package main\n\n" +
+				"type T struct {\n\tA string `yaml:\"a\" json:\"a\"`\n}\n\n" +
+				"func main() {\n\t// Print.\n\tfmt.Println(a, b, c...)\n}\n",
+			expected: `the comment "// Print the values." was lost`,
+		},
+		{
+			desc:     "syntax error",
+			output:   "package main\n\nfunc main() {\n",
+			expected: "the output doesn't parse: 3:15: expected '}', found 'EOF'",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, verify([]byte(input), []byte(test.output)))
+		})
+	}
+}
+
+func Test_minimize(t *testing.T) {
+	content := []byte(`package main
+
+import "fmt"
+
+const c = 1
+
+// f does things.
+func f() {
+	fmt.Println("a")
+	fmt.Println("bad")
+	fmt.Println("b")
+}
+
+type T struct{}
+`)
+
+	testCases := []struct {
+		desc       string
+		reproduces func([]byte) bool
+		expected   string
+	}{
+		{
+			desc:       "statement",
+			reproduces: func(candidate []byte) bool { return bytes.Contains(candidate, []byte("bad")) },
+			expected:   "package main\n\nimport \"fmt\"\n\n// f does things.\nfunc f() {\n\tfmt.Println(\"bad\")\n}",
+		},
+		{
+			desc: "declaration",
+			reproduces: func(candidate []byte) bool {
+				return bytes.Contains(candidate, []byte(`"a"`)) && bytes.Contains(candidate, []byte(`"b"`))
+			},
+			expected: "package main\n\nimport \"fmt\"\n\n// f does things.\nfunc f() {\n" +
+				"\tfmt.Println(\"a\")\n\tfmt.Println(\"bad\")\n\tfmt.Println(\"b\")\n}",
+		},
+		{
+			desc: "interaction between declarations",
+			reproduces: func(candidate []byte) bool {
+				return bytes.Contains(candidate, []byte("const c")) && bytes.Contains(candidate, []byte("type T"))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, string(minimize(content, test.reproduces)))
+		})
+	}
+}
+
+func TestShortener_verifyProcessing_trace(t *testing.T) {
+	config := NewDefaultConfig()
+	config.MaxLen = 40
+	config.Trace = true
+
+	s := NewShortener(config)
+	s.tracer = newTracer("main.go", config)
+
+	content := []byte("package main\n\nfunc main() {\n\tfmt.Println(\"aaaaaaaaaaaa\", \"bbbbbbbbbbbb\", \"cccccccccccc\")\n}\n")
+
+	assert.Empty(t, s.verifyProcessing("main.go", content))
+	assert.Empty(t, s.tracer.events)
+}
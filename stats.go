@@ -0,0 +1,241 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golangci/golines/shorten"
+)
+
+// Formats of the statistics.
+const (
+	statsText = "text"
+	statsJSON = "json"
+)
+
+// nbSlowestFiles is the number of the slowest files listed in the statistics.
+const nbSlowestFiles = 5
+
+// statsConstructs are the kinds of constructs, in the order of the statistics.
+var statsConstructs = []shorten.Construct{
+	shorten.ConstructCallArgs,
+	shorten.ConstructChains,
+	shorten.ConstructCompositeLits,
+	shorten.ConstructSignatures,
+	shorten.ConstructConditions,
+	shorten.ConstructTags,
+	shorten.ConstructComments,
+}
+
+// runStats aggregates the statistics of the processed files.
+// The files are processed concurrently.
+type runStats struct {
+	format string
+
+	mu             sync.Mutex
+	scanned        int
+	generated      int
+	shortened      int
+	linesShortened int
+	linesOverLimit int
+	rounds         int
+	maxRoundsHit   int
+	constructs     map[shorten.Construct]int
+	slowest        []fileDuration
+}
+
+type fileDuration struct {
+	path     string
+	duration time.Duration
+}
+
+func newRunStats(format string) *runStats {
+	if format == "" {
+		return nil
+	}
+
+	return &runStats{
+		format:     format,
+		constructs: map[shorten.Construct]int{},
+	}
+}
+
+// addScanned records a file read.
+func (st *runStats) addScanned() {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.scanned++
+}
+
+// addGenerated records a file skipped as generated.
+func (st *runStats) addGenerated() {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.generated++
+}
+
+// addShortened records the counters of a shortened file, and its processing time.
+func (st *runStats) addShortened(path string, stats shorten.Stats, duration time.Duration) {
+	if st == nil {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.shortened++
+	st.linesShortened += stats.LinesShortened
+	st.linesOverLimit += stats.LinesOverLimit
+	st.rounds += stats.Rounds
+
+	if stats.MaxRoundsHit {
+		st.maxRoundsHit++
+	}
+
+	for construct, n := range stats.Constructs {
+		st.constructs[construct] += n
+	}
+
+	st.slowest = append(st.slowest, fileDuration{path: path, duration: duration})
+
+	slices.SortStableFunc(st.slowest, func(a, b fileDuration) int {
+		return cmp.Compare(b.duration, a.duration)
+	})
+
+	st.slowest = st.slowest[:min(len(st.slowest), nbSlowestFiles)]
+}
+
+// statsReport is the JSON format of the statistics.
+type statsReport struct {
+	Files      statsFiles     `json:"files"`
+	Lines      statsLines     `json:"lines"`
+	Rounds     statsRounds    `json:"rounds"`
+	Constructs map[string]int `json:"constructs"`
+	Slowest    []statsFile    `json:"slowest"`
+}
+
+type statsFiles struct {
+	Scanned   int `json:"scanned"`
+	Changed   int `json:"changed"`
+	Generated int `json:"generated"`
+	Errored   int `json:"errored"`
+}
+
+type statsLines struct {
+	Shortened int `json:"shortened"`
+	OverLimit int `json:"over_limit"`
+}
+
+type statsRounds struct {
+	Average      float64 `json:"average"`
+	MaxRoundsHit int     `json:"max_rounds_hit"`
+}
+
+type statsFile struct {
+	Path       string  `json:"path"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// addTo adds a task printing the statistics to the error stream, after the output of any previously-added tasks.
+func (st *runStats) addTo(s *sequencer) {
+	s.Add(0, func(r *reporter) error {
+		state := r.getState()
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		report := statsReport{
+			Files: statsFiles{
+				Scanned:   st.scanned,
+				Changed:   state.changed,
+				Generated: st.generated,
+				Errored:   state.errored,
+			},
+			Lines: statsLines{
+				Shortened: st.linesShortened,
+				OverLimit: st.linesOverLimit,
+			},
+			Rounds: statsRounds{
+				MaxRoundsHit: st.maxRoundsHit,
+			},
+			Constructs: map[string]int{},
+			Slowest:    []statsFile{},
+		}
+
+		if st.shortened > 0 {
+			report.Rounds.Average = float64(st.rounds) / float64(st.shortened)
+		}
+
+		for _, construct := range statsConstructs {
+			report.Constructs[string(construct)] = st.constructs[construct]
+		}
+
+		for _, f := range st.slowest {
+			report.Slowest = append(report.Slowest, statsFile{
+				Path:       f.path,
+				DurationMS: float64(f.duration.Microseconds()) / 1000,
+			})
+		}
+
+		if st.format == statsJSON {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			r.Warnf("%s\n", data)
+
+			return nil
+		}
+
+		r.Warnf("%s", report.text())
+
+		return nil
+	})
+}
+
+func (report statsReport) text() string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "golines: %d files scanned, %d changed, %d skipped as generated, %d errored\n",
+		report.Files.Scanned, report.Files.Changed, report.Files.Generated, report.Files.Errored)
+
+	_, _ = fmt.Fprintf(&b, "golines: %d lines shortened, %d lines still over the limit\n",
+		report.Lines.Shortened, report.Lines.OverLimit)
+
+	_, _ = fmt.Fprintf(&b, "golines: %.1f rounds on average, maximum number of rounds hit %d times\n",
+		report.Rounds.Average, report.Rounds.MaxRoundsHit)
+
+	b.WriteString("golines: constructs:")
+
+	for _, construct := range statsConstructs {
+		_, _ = fmt.Fprintf(&b, " %s=%d", construct, report.Constructs[string(construct)])
+	}
+
+	b.WriteString("\n")
+
+	if len(report.Slowest) > 0 {
+		b.WriteString("golines: slowest files:\n")
+
+		for _, f := range report.Slowest {
+			_, _ = fmt.Fprintf(&b, "  %8.1fms %s\n", f.DurationMS, f.Path)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golangci/golines/shorten"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runner_run_stats(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"test1.go":          testFiles["test1.go"],
+		"test3.go":          "package main\n",
+		"generated_test.go": testFiles["test1.go"],
+		"header.go":         "// Code generated by hand. DO NOT EDIT.\n\npackage main\n",
+	}, root)
+
+	t.Chdir(root)
+
+	runner := NewRunner()
+	runner.args = []string{"."}
+	runner.listFiles = true
+	runner.ignoreGenerated = true
+	runner.generated = &generatedDetector{mode: generatedModeLax, filePatterns: []string{"generated_*"}}
+	runner.stats = newRunStats(statsJSON)
+	runner.shortener = shorten.NewShortener(nil)
+	runner.selector = newFileSelector(nil, nil, nil, false)
+
+	var stdout, stderr bytes.Buffer
+
+	s := newSequencer(1, &stdout, &stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	var report statsReport
+
+	require.NoError(t, json.Unmarshal(stderr.Bytes(), &report))
+
+	assert.Equal(t, statsFiles{Scanned: 3, Changed: 1, Generated: 2}, report.Files)
+	assert.Equal(t, "test1.go\n", stdout.String())
+	assert.Positive(t, report.Lines.Shortened)
+	assert.InDelta(t, 0.5, report.Rounds.Average, 0.001)
+	assert.Positive(t, report.Constructs["call_args"])
+	assert.Len(t, report.Constructs, len(statsConstructs))
+	assert.Len(t, report.Slowest, 2)
+}
+
+func Test_statsReport_text(t *testing.T) {
+	report := statsReport{
+		Files:      statsFiles{Scanned: 3, Changed: 1, Generated: 2},
+		Lines:      statsLines{Shortened: 4, OverLimit: 1},
+		Rounds:     statsRounds{Average: 1.5},
+		Constructs: map[string]int{"call_args": 2, "tags": 1},
+		Slowest:    []statsFile{{Path: "a.go", DurationMS: 1.25}},
+	}
+
+	expected := []string{
+		"golines: 3 files scanned, 1 changed, 2 skipped as generated, 0 errored",
+		"golines: 4 lines shortened, 1 lines still over the limit",
+		"golines: 1.5 rounds on average, maximum number of rounds hit 0 times",
+		"golines: constructs: call_args=2 chains=0 composite_literals=0 signatures=0 conditions=0 tags=1 comments=0",
+		"golines: slowest files:",
+		"       1.2ms a.go",
+	}
+
+	assert.Equal(t, strings.Join(expected, "\n")+"\n", report.text())
+}
@@ -0,0 +1,9 @@
+// @generated by a tool that doesn't follow the Go convention.
+
+package testdata
+
+import "fmt"
+
+func World() {
+	fmt.Println("world")
+}
@@ -0,0 +1,9 @@
+// This file was generated by a tool, it should not be edited by hand.
+
+package testdata
+
+import "fmt"
+
+func Hello() {
+	fmt.Println("hello")
+}
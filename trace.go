@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/golangci/golines/shorten"
+)
+
+// traceFile collects the decisions of the shortener, and writes them as JSON lines, one event per line.
+// The events are grouped by file, sorted by name.
+type traceFile struct {
+	file string
+
+	mu     sync.Mutex
+	blocks []traceBlock
+}
+
+// traceBlock is the JSON lines of the events of a file.
+type traceBlock struct {
+	filename string
+	data     []byte
+}
+
+func newTraceFile(file string) *traceFile {
+	if file == "" {
+		return nil
+	}
+
+	return &traceFile{file: file}
+}
+
+// add adds the events of a file.
+func (t *traceFile) add(filename string, events []shorten.TraceEvent) error {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+
+	for _, event := range events {
+		err := encoder.Encode(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.blocks = append(t.blocks, traceBlock{filename: filename, data: buf.Bytes()})
+
+	return nil
+}
+
+// write writes the trace file, once all the files are processed.
+func (t *traceFile) write() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	slices.SortStableFunc(t.blocks, func(a, b traceBlock) int {
+		return cmp.Compare(a.filename, b.filename)
+	})
+
+	var buf bytes.Buffer
+
+	for _, block := range t.blocks {
+		buf.Write(block.data)
+	}
+
+	return os.WriteFile(t.file, buf.Bytes(), 0o644)
+}
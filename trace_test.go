@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golangci/golines/shorten"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_runner_run_trace(t *testing.T) {
+	root := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"test1.go": testFiles["test1.go"],
+		"test2.go": testFiles["test1.go"],
+		"test3.go": "package main\n",
+	}, root)
+
+	t.Chdir(root)
+
+	traceFile := filepath.Join(t.TempDir(), "trace.jsonl")
+
+	config := shorten.NewDefaultConfig()
+	config.Trace = true
+
+	runner := NewRunner()
+	runner.args = []string{"."}
+	runner.listFiles = true
+	runner.trace = newTraceFile(traceFile)
+	runner.shortener = shorten.NewShortener(config)
+	runner.selector = newFileSelector(nil, nil, nil, false)
+
+	s := newSequencer(1, &bytes.Buffer{}, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	file, err := os.Open(traceFile)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = file.Close() })
+
+	var files []string
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		var event shorten.TraceEvent
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+
+		assert.Positive(t, event.Line)
+		assert.NotEmpty(t, event.Rule)
+
+		if len(files) == 0 || files[len(files)-1] != event.File {
+			files = append(files, event.File)
+		}
+	}
+
+	require.NoError(t, scanner.Err())
+
+	// The events are grouped by file.
+	assert.Equal(t, []string{"test1.go", "test2.go"}, files)
+}
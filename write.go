@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeFile atomically replaces a file with the new formatted data:
+// the data is written to a temporary file in the same directory, synced,
+// and then renamed over the original file, keeping its mode and ownership.
+// A crash can leave a temporary file behind, but never a truncated file.
+//
+// With a backup suffix, the original file is kept as filename+suffix.
+func writeFile(filename string, orig, formatted []byte, backupSuffix string) error {
+	// The target of a symbolic link is replaced, not the link itself.
+	target, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+
+	fdSem <- true
+	defer func() { <-fdSem }()
+
+	dir, base := filepath.Split(target)
+
+	tmp, err := os.CreateTemp(dir, "."+base+".golines-*")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	err = writeTemp(tmp, formatted, info)
+	if err != nil {
+		_ = os.Remove(tmpName)
+
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	if backupSuffix != "" {
+		err = backupFile(target, target+backupSuffix, orig, info.Mode().Perm())
+		if err != nil {
+			_ = os.Remove(tmpName)
+
+			return err
+		}
+	}
+
+	err = os.Rename(tmpName, target)
+	if err != nil {
+		_ = os.Remove(tmpName)
+
+		return err
+	}
+
+	return nil
+}
+
+// writeTemp writes the content of the temporary file, with the mode and the ownership of the original file.
+func writeTemp(tmp *os.File, content []byte, info fs.FileInfo) error {
+	_, err := tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if err == nil {
+		err = tmp.Chmod(info.Mode().Perm())
+	}
+
+	if err == nil {
+		err = chown(tmp, info)
+	}
+
+	if errc := tmp.Close(); err == nil {
+		err = errc
+	}
+
+	return err
+}
+
+// backupFile keeps the original file as backup, replacing the previous backup (if any).
+// The backup is a hard link to the original file when possible, otherwise a copy of its content.
+func backupFile(filename, backup string, orig []byte, perm fs.FileMode) error {
+	err := os.Remove(backup)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if os.Link(filename, backup) == nil {
+		return nil
+	}
+
+	return os.WriteFile(backup, orig, perm)
+}
+
+// restoreBackups puts the backups made with the backup suffix back in place of the files,
+// in the provided paths: files, directories, or package patterns.
+// A file is either the formatted file or its backup, it's an error if it has no backup.
+func (r *Runner) restoreBackups(s *sequencer, paths []string, backupSuffix string) {
+	if backupSuffix == "" {
+		s.AddReport(errors.New("the backup suffix is required (--backup)"))
+
+		return
+	}
+
+	restore := func(backup string) {
+		original := strings.TrimSuffix(backup, backupSuffix)
+
+		err := os.Rename(backup, original)
+		if err != nil {
+			s.AddReport(err)
+
+			return
+		}
+
+		s.Add(0, func(rp *reporter) error {
+			if r.listFiles {
+				_, _ = fmt.Fprintln(rp, original)
+			}
+
+			return nil
+		})
+	}
+
+	for _, arg := range paths {
+		if info, err := os.Stat(arg); !isPattern(arg) && (err != nil || !info.IsDir()) {
+			backup := arg
+			if !strings.HasSuffix(arg, backupSuffix) {
+				backup += backupSuffix
+			}
+
+			switch _, err = os.Stat(backup); {
+			case errors.Is(err, fs.ErrNotExist):
+				s.AddReport(fmt.Errorf("%s: no backup found (%s)", arg, backup))
+			case err != nil:
+				s.AddReport(err)
+			default:
+				restore(backup)
+			}
+
+			continue
+		}
+
+		root, match := arg, func(string) bool { return true }
+
+		if isPattern(arg) {
+			root, match = patternRoot(arg), matchPattern(arg)
+		}
+
+		err := filepath.WalkDir(root, func(path string, f fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if f.IsDir() {
+				if path != root && r.selector.isExcluded(path, true) {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if strings.HasSuffix(path, ".go"+backupSuffix) && match(filepath.Dir(path)) {
+				restore(path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			s.AddReport(err)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"io/fs"
+	"os"
+)
+
+// chown does nothing: the ownership is not kept on this platform.
+func chown(_ *os.File, _ fs.FileInfo) error {
+	return nil
+}
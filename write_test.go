@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeFile(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "test.go")
+
+	require.NoError(t, os.WriteFile(filename, []byte("original"), 0o600))
+
+	err := writeFile(filename, []byte("original"), []byte("formatted"), "")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "formatted", string(content))
+
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// No temporary file is left behind.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func Test_writeFile_backup(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "test.go")
+
+	require.NoError(t, os.WriteFile(filename, []byte("original"), 0o644))
+	require.NoError(t, os.WriteFile(filename+".orig", []byte("old backup"), 0o644))
+
+	err := writeFile(filename, []byte("original"), []byte("formatted"), ".orig")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "formatted", string(content))
+
+	backup, err := os.ReadFile(filename + ".orig")
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(backup))
+}
+
+func Test_writeFile_symlink(t *testing.T) {
+	dir := t.TempDir()
+
+	filename := filepath.Join(dir, "test.go")
+	link := filepath.Join(dir, "link.go")
+
+	require.NoError(t, os.WriteFile(filename, []byte("original"), 0o644))
+
+	err := os.Symlink(filename, link)
+	if err != nil {
+		t.Skipf("symbolic links not supported: %v", err)
+	}
+
+	err = writeFile(link, []byte("original"), []byte("formatted"), "")
+	require.NoError(t, err)
+
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "formatted", string(content))
+}
+
+func Test_runner_restoreBackups(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runner := NewRunner()
+	runner.writeOutput = true
+	runner.backupSuffix = ".orig"
+	runner.args = writeTestFiles(t, testFiles, tmpDir)
+	runner.selector = newFileSelector(nil, nil, nil, false)
+
+	s := newSequencer(1, os.Stdout, os.Stderr)
+
+	runner.run(s)
+	require.Equal(t, 0, s.GetExitCode())
+
+	for name, expected := range testFiles {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		require.NoError(t, err)
+		assert.NotEqual(t, expected, string(content))
+
+		backup, err := os.ReadFile(filepath.Join(tmpDir, name+".orig"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(backup))
+	}
+
+	var buf bytes.Buffer
+
+	s = newSequencer(1, &buf, os.Stderr)
+
+	runner.listFiles = true
+	runner.restoreBackups(s, []string{tmpDir}, ".orig")
+	require.Equal(t, 0, s.GetExitCode())
+
+	assert.Equal(t, filepath.Join(tmpDir, "test1.go")+"\n"+filepath.Join(tmpDir, "test2.go")+"\n", buf.String())
+
+	for name, expected := range testFiles {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name))
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+
+		_, err = os.Stat(filepath.Join(tmpDir, name+".orig"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+}
+
+func Test_runner_restoreBackups_noSuffix(t *testing.T) {
+	var buf bytes.Buffer
+
+	s := newSequencer(1, os.Stdout, &buf)
+
+	NewRunner().restoreBackups(s, []string{"."}, "")
+
+	require.Equal(t, 2, s.GetExitCode())
+	assert.Equal(t, "the backup suffix is required (--backup)\n", buf.String())
+}
+
+func Test_runner_restoreBackups_files(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFiles(t, map[string]string{
+		"a.go":      "package a // formatted\n",
+		"a.go.orig": "package a\n",
+		"b.go":      "package b // formatted\n",
+		"b.go.orig": "package b\n",
+		"c.go":      "package c\n",
+	}, tmpDir)
+
+	var stdout, stderr bytes.Buffer
+
+	s := newSequencer(1, &stdout, &stderr)
+
+	runner := NewRunner()
+	runner.listFiles = true
+
+	// A file is given by its name or by the name of its backup.
+	runner.restoreBackups(s, []string{
+		filepath.Join(tmpDir, "a.go"),
+		filepath.Join(tmpDir, "b.go.orig"),
+		filepath.Join(tmpDir, "c.go"),
+	}, ".orig")
+	require.Equal(t, 2, s.GetExitCode())
+
+	assert.Equal(t, filepath.Join(tmpDir, "a.go")+"\n"+filepath.Join(tmpDir, "b.go")+"\n", stdout.String())
+	assert.Equal(t, filepath.Join(tmpDir, "c.go")+": no backup found ("+filepath.Join(tmpDir, "c.go.orig")+")\n",
+		stderr.String())
+
+	for _, name := range []string{"a", "b", "c"} {
+		content, err := os.ReadFile(filepath.Join(tmpDir, name+".go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package "+name+"\n", string(content))
+	}
+}